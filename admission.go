@@ -0,0 +1,64 @@
+package distcache
+
+import (
+	"sync/atomic"
+
+	"github.com/simplely77/distcache/bloomfilter"
+	"github.com/simplely77/distcache/countminsketch"
+)
+
+// DefaultAdmissionSamples TinyLFU 的采样窗口，记录这么多次访问后重置门卫并将计数减半
+const DefaultAdmissionSamples = 100000
+
+// tinyLFU 是 W-TinyLFU 中的准入过滤器：
+// 第一次出现的 key 只记在门卫布隆过滤器里，再次出现才计入 count-min sketch，
+// 分片满时只有新 key 的估计频率高于淘汰候选时才允许写入，避免一次性访问冲掉热数据
+type tinyLFU struct {
+	doorkeeper *bloomfilter.BloomFilter
+	sketch     *countminsketch.CountMinSketch
+	samples    uint64 // 当前窗口内的访问次数，原子操作
+	window     uint64
+}
+
+func newTinyLFU(samples uint) *tinyLFU {
+	if samples == 0 {
+		samples = DefaultAdmissionSamples
+	}
+	return &tinyLFU{
+		doorkeeper: bloomfilter.NewBloomFilterWithEstimates(samples, 0.01),
+		sketch:     countminsketch.NewCountMinSketch(0.0001, 0.01),
+		window:     uint64(samples),
+	}
+}
+
+// record 记录一次访问
+func (t *tinyLFU) record(key string) {
+	if t.doorkeeper.Test(key) {
+		t.sketch.Add(key, 1)
+	} else {
+		t.doorkeeper.Add(key)
+	}
+	if n := atomic.AddUint64(&t.samples, 1); n >= t.window && atomic.CompareAndSwapUint64(&t.samples, n, 0) {
+		t.reset()
+	}
+}
+
+// reset 清空门卫并将计数减半，使频率统计跟随访问模式变化
+func (t *tinyLFU) reset() {
+	t.doorkeeper.Reset()
+	t.sketch.Decay()
+}
+
+// estimate 估计 key 在当前窗口内的访问频率，门卫中存在时额外加一
+func (t *tinyLFU) estimate(key string) uint64 {
+	n := t.sketch.Count(key)
+	if t.doorkeeper.Test(key) {
+		n++
+	}
+	return n
+}
+
+// admit 判断候选 key 是否可以替换淘汰候选 victim
+func (t *tinyLFU) admit(candidate, victim string) bool {
+	return t.estimate(candidate) > t.estimate(victim)
+}
@@ -0,0 +1,273 @@
+package arc
+
+import (
+	"container/list"
+
+	"github.com/simplely77/distcache/lru"
+)
+
+// Value 与 lru.Value 相同，使不同的淘汰策略可以互相替换
+type Value = lru.Value
+
+// Cache 是按字节计算容量的 ARC（Adaptive Replacement Cache）
+// t1 保存只访问过一次的条目，t2 保存访问过多次的条目，
+// b1、b2 是它们被淘汰条目的幽灵记录（只保存 key 和大小），用来自适应调整 t1 的目标大小 p
+type Cache struct {
+	// 最大内存
+	maxBytes int64
+	// 由外部调用 RemoveOldest 淘汰时（多个 Cache 共享总容量），最近一次淘汰前的常驻字节数，
+	// 作为幽灵记录和 p 使用的容量，见 capacity
+	target int64
+	// t1 的目标字节数
+	p int64
+	// 常驻链表，队首为最近使用
+	t1, t2 *list.List
+	// 幽灵链表，队首为最近淘汰
+	b1, b2 *list.List
+	// 各链表的字节数
+	t1Bytes, t2Bytes, b1Bytes, b2Bytes int64
+	// 常驻条目映射
+	cache map[string]*list.Element
+	// 幽灵条目映射
+	ghosts map[string]*list.Element
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted func(key string, val Value)
+}
+
+type entry struct {
+	key      string
+	value    Value
+	frequent bool // 是否在 t2 中
+}
+
+func (e *entry) size() int64 {
+	return int64(len(e.key)) + int64(e.value.Len())
+}
+
+type ghost struct {
+	key      string
+	size     int64
+	frequent bool // 是否在 b2 中
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		t1:        list.New(),
+		t2:        list.New(),
+		b1:        list.New(),
+		b2:        list.New(),
+		cache:     make(map[string]*list.Element),
+		ghosts:    make(map[string]*list.Element),
+		onEvicted: onEvicted,
+	}
+}
+
+// Add 添加一个键值对到缓存中
+func (c *Cache) Add(key string, val Value) {
+	if ele, ok := c.cache[key]; ok {
+		// 已存在的键视为一次命中，移动到 t2
+		kv := ele.Value.(*entry)
+		c.unlink(ele)
+		kv.value = val
+		c.pushFrequent(kv)
+		c.evict(false)
+		return
+	}
+
+	kv := &entry{key: key, value: val}
+	if ele, ok := c.ghosts[key]; ok {
+		// 命中幽灵记录，说明之前淘汰得太早，调整 t1 的目标大小
+		g := ele.Value.(*ghost)
+		c.removeGhost(ele)
+		if g.frequent {
+			c.p -= delta(g.size, c.b1Bytes, c.b2Bytes)
+			if c.p < 0 {
+				c.p = 0
+			}
+		} else {
+			c.p += delta(g.size, c.b2Bytes, c.b1Bytes)
+			if capacity := c.capacity(); c.p > capacity {
+				c.p = capacity
+			}
+		}
+		c.pushFrequent(kv)
+		c.evict(g.frequent)
+		return
+	}
+
+	c.cache[key] = c.t1.PushFront(kv)
+	c.t1Bytes += kv.size()
+	c.evict(false)
+}
+
+// Get 查找键对应的值，命中的条目移动到 t2 队首
+func (c *Cache) Get(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		c.unlink(ele)
+		c.pushFrequent(kv)
+		return kv.value, true
+	}
+	return
+}
+
+// Peek 查找键对应的值，但不改变其所在的链表和位置
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Victim 返回下一个会被淘汰的键，但不淘汰它
+func (c *Cache) Victim() (key string, ok bool) {
+	if ele := c.victim(false); ele != nil {
+		return ele.Value.(*entry).key, true
+	}
+	return
+}
+
+// RemoveOldest 按 ARC 的替换规则淘汰一个常驻条目
+// 外部调用时把淘汰前的常驻字节数当作当前容量，使幽灵记录与实际容量相称
+func (c *Cache) RemoveOldest() {
+	c.target = c.t1Bytes + c.t2Bytes
+	c.replace(false)
+	c.trimGhosts()
+}
+
+// capacity 返回 ARC 的容量 c：maxBytes 为 0 或外部淘汰时的容量更小时使用后者
+func (c *Cache) capacity() int64 {
+	if c.target > 0 && (c.maxBytes == 0 || c.target < c.maxBytes) {
+		return c.target
+	}
+	return c.maxBytes
+}
+
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		c.unlink(ele)
+		delete(c.cache, key)
+		if c.onEvicted != nil {
+			c.onEvicted(kv.key, kv.value)
+		}
+	}
+	if ele, ok := c.ghosts[key]; ok {
+		c.removeGhost(ele)
+	}
+}
+
+// Range 先遍历 t1 再遍历 t2，每个链表内从最久未使用到最近使用，不包括幽灵记录，
+// fn 返回 false 时停止，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for _, l := range []*list.List{c.t1, c.t2} {
+		for ele := l.Back(); ele != nil; ele = ele.Prev() {
+			kv := ele.Value.(*entry)
+			if !fn(kv.key, kv.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// NBytes 返回当前缓存使用的字节数（不包括幽灵记录）
+func (c *Cache) NBytes() int64 {
+	return c.t1Bytes + c.t2Bytes
+}
+
+// evict 在超出容量时淘汰常驻条目，并限制幽灵记录的大小
+func (c *Cache) evict(hitB2 bool) {
+	for c.maxBytes != 0 && c.maxBytes < c.t1Bytes+c.t2Bytes {
+		c.replace(hitB2)
+	}
+	c.trimGhosts()
+}
+
+// trimGhosts 按容量限制幽灵记录：t1+b1 不超过 c，全部链表不超过 2c
+func (c *Cache) trimGhosts() {
+	capacity := c.capacity()
+	if capacity == 0 {
+		return
+	}
+	for c.t1Bytes+c.b1Bytes > capacity && c.b1.Len() > 0 {
+		c.removeGhost(c.b1.Back())
+	}
+	for c.t1Bytes+c.t2Bytes+c.b1Bytes+c.b2Bytes > 2*capacity && c.b2.Len() > 0 {
+		c.removeGhost(c.b2.Back())
+	}
+}
+
+// replace 淘汰 t1 或 t2 中最久未使用的条目，并记录为幽灵
+func (c *Cache) replace(hitB2 bool) {
+	ele := c.victim(hitB2)
+	if ele == nil {
+		return
+	}
+	kv := ele.Value.(*entry)
+	c.unlink(ele)
+	delete(c.cache, kv.key)
+
+	g := &ghost{key: kv.key, size: kv.size(), frequent: kv.frequent}
+	if g.frequent {
+		c.ghosts[g.key] = c.b2.PushFront(g)
+		c.b2Bytes += g.size
+	} else {
+		c.ghosts[g.key] = c.b1.PushFront(g)
+		c.b1Bytes += g.size
+	}
+
+	if c.onEvicted != nil {
+		c.onEvicted(kv.key, kv.value)
+	}
+}
+
+// victim 按 ARC 的替换规则选择 t1 或 t2 中最久未使用的条目
+func (c *Cache) victim(hitB2 bool) *list.Element {
+	if c.t1.Len() > 0 && (c.t1Bytes > c.p || (hitB2 && c.t1Bytes == c.p) || c.t2.Len() == 0) {
+		return c.t1.Back()
+	}
+	return c.t2.Back()
+}
+
+func (c *Cache) pushFrequent(kv *entry) {
+	kv.frequent = true
+	c.cache[kv.key] = c.t2.PushFront(kv)
+	c.t2Bytes += kv.size()
+}
+
+// unlink 将常驻条目从所在链表中移除，不修改映射
+func (c *Cache) unlink(ele *list.Element) {
+	kv := ele.Value.(*entry)
+	if kv.frequent {
+		c.t2.Remove(ele)
+		c.t2Bytes -= kv.size()
+	} else {
+		c.t1.Remove(ele)
+		c.t1Bytes -= kv.size()
+	}
+}
+
+func (c *Cache) removeGhost(ele *list.Element) {
+	g := ele.Value.(*ghost)
+	if g.frequent {
+		c.b2.Remove(ele)
+		c.b2Bytes -= g.size
+	} else {
+		c.b1.Remove(ele)
+		c.b1Bytes -= g.size
+	}
+	delete(c.ghosts, g.key)
+}
+
+// delta 计算命中幽灵记录时 p 的调整量，命中的幽灵链表越小调整越大
+func delta(size, other, hit int64) int64 {
+	if hit > 0 && other > hit {
+		return size * other / hit
+	}
+	return size
+}
@@ -0,0 +1,120 @@
+package arc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+// test Get method of Cache
+func TestGet(t *testing.T) {
+	arc := New(int64(0), nil)
+	arc.Add("key1", String("1234"))
+	if v, ok := arc.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := arc.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+// test RemoveOldest method of Cache
+func TestRemoveOldest(t *testing.T) {
+	k1, k2, k3 := "key1", "key2", "k3"
+	v1, v2, v3 := "value1", "value2", "v3"
+	cap := len(k1 + k2 + v1 + v2)
+	arc := New(int64(cap), nil)
+	arc.Add(k1, String(v1))
+	arc.Add(k2, String(v2))
+	arc.Add(k3, String(v3))
+	if _, ok := arc.Get(k1); ok || arc.Len() != 2 {
+		t.Fatalf("RemoveOldest key1 failed")
+	}
+}
+
+// test OnEvicted callback function of Cache
+func TestOnEvicted(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	arc := New(int64(10), callback)
+	arc.Add("key1", String("123456"))
+	arc.Add("k2", String("v2"))
+	arc.Add("k3", String("v3"))
+	arc.Add("k4", String("v4"))
+	expect := []string{"key1", "k2"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("OnEvicted keys = %v, want %v", keys, expect)
+	}
+}
+
+// test frequently used entries survive a one-time scan
+func TestScanResistance(t *testing.T) {
+	arc := New(int64(100), nil)
+	// 热数据：4 个条目，每个 10 字节，访问两次进入 t2
+	for i := 0; i < 4; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		arc.Add(key, String("value1"))
+		arc.Get(key)
+	}
+	// 一次性扫描大量冷数据
+	for i := 0; i < 100; i++ {
+		arc.Add(fmt.Sprintf("cold%02d", i), String("val"))
+	}
+	for i := 0; i < 4; i++ {
+		if _, ok := arc.Get(fmt.Sprintf("hot%d", i)); !ok {
+			t.Fatalf("hot%d should survive the scan", i)
+		}
+	}
+	if arc.NBytes() > 100 {
+		t.Fatalf("NBytes %d exceeds maxBytes", arc.NBytes())
+	}
+}
+
+// test ghost hits adapt the target size and bring entries back to t2
+func TestGhostHit(t *testing.T) {
+	arc := New(int64(30), nil)
+	arc.Add("k1", String("vvvvvvvv"))
+	arc.Add("k2", String("vvvvvvvv"))
+	arc.Get("k1")
+	arc.Add("k3", String("vvvvvvvv"))
+	arc.Add("k4", String("vvvvvvvv"))
+	if _, ok := arc.ghosts["k2"]; !ok {
+		t.Fatalf("evicted k2 should be remembered as ghost")
+	}
+	arc.Add("k2", String("vvvvvvvv"))
+	if arc.p == 0 {
+		t.Fatalf("ghost hit in b1 should increase p")
+	}
+	if ele, ok := arc.cache["k2"]; !ok || !ele.Value.(*entry).frequent {
+		t.Fatalf("ghost hit should insert k2 into t2")
+	}
+	arc.Remove("k2")
+	if arc.NBytes() != arc.t1Bytes+arc.t2Bytes || arc.Len() != arc.t1.Len()+arc.t2.Len() {
+		t.Fatalf("inconsistent accounting after Remove")
+	}
+}
+
+// test ghost lists are bounded by the capacity seen by external RemoveOldest calls
+func TestExternalEviction(t *testing.T) {
+	arc := New(int64(1<<20), nil)
+	for i := 0; i < 1000; i++ {
+		arc.Add(fmt.Sprintf("k%03d", i), String("v"))
+		for arc.NBytes() > 40 {
+			arc.RemoveOldest()
+		}
+	}
+	if ghosts := arc.b1Bytes + arc.b2Bytes; ghosts > 2*40 || len(arc.ghosts) > 20 {
+		t.Fatalf("ghost lists not bounded: %d bytes, %d entries", ghosts, len(arc.ghosts))
+	}
+	if arc.p > 40 {
+		t.Fatalf("p = %d exceeds capacity", arc.p)
+	}
+}
@@ -0,0 +1,252 @@
+package distcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GetResult 是批量获取中单个 key 的结果
+type GetResult struct {
+	Value ByteView
+	Err   error
+}
+
+// BatchGetter 批量从数据源获取数据，返回结果中缺失的 key 视为不存在（ErrNotFound）
+// 如果 Group 的 getter 实现了 BatchGetter，GetMany 会用它一次性加载本地未命中的 key
+type BatchGetter interface {
+	GetMany(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// BatchGetterFunc 函数式实现 BatchGetter，同时实现了 Getter 和 ContextGetter
+type BatchGetterFunc func(ctx context.Context, keys []string) (map[string][]byte, error)
+
+func (f BatchGetterFunc) Get(key string) ([]byte, error) {
+	return f.GetContext(context.Background(), key)
+}
+
+func (f BatchGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	values, err := f(ctx, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	v, ok := values[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (f BatchGetterFunc) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return f(ctx, keys)
+}
+
+// GetMany 批量获取多个 key，返回每个 key 的结果
+func (g *Group) GetMany(keys []string) map[string]GetResult {
+	return g.GetManyContext(context.Background(), keys)
+}
+
+// GetManyContext 批量获取多个 key：先查本地缓存，未命中的 key 按一致性哈希的主节点分组，
+// 每个远程节点只发送一次 BatchGet 请求，本节点负责的 key 通过 BatchGetter 一次性加载
+func (g *Group) GetManyContext(ctx context.Context, keys []string) map[string]GetResult {
+	start := time.Now()
+	results := make(map[string]GetResult, len(keys))
+	var mu sync.Mutex
+	setResult := func(key string, value ByteView, err error) {
+		mu.Lock()
+		results[key] = GetResult{Value: value, Err: err}
+		mu.Unlock()
+	}
+
+	if g.closed.Load() {
+		for _, key := range keys {
+			results[key] = GetResult{Err: ErrGroupClosed}
+		}
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("get_many", "error")
+			GetMetrics().RecordDuration("get_many", "error", time.Since(start).Seconds())
+		}
+		return results
+	}
+
+	// 先查本地缓存，同时按主节点对未命中的 key 分组
+	var localKeys []string
+	peerKeys := make(map[PeerClient][]string)
+	for _, key := range keys {
+		if _, done := results[key]; done {
+			continue
+		}
+		if key == "" {
+			results[key] = GetResult{Err: fmt.Errorf("key is required")}
+			continue
+		}
+		if v, ok := g.mainCache.get(key); ok {
+			if v.negative {
+				results[key] = GetResult{Err: ErrNotFound}
+			} else {
+				g.maybeRefresh(key, v)
+				results[key] = GetResult{Value: v}
+			}
+			continue
+		}
+		if !g.mayExist(key) {
+			results[key] = GetResult{Err: ErrNotFound}
+			continue
+		}
+		// 占位，防止重复的 key 被多次加载
+		results[key] = GetResult{}
+		if g.peers != nil {
+			if peer, ok := g.peers.PickPeer(key); ok {
+				peerKeys[peer] = append(peerKeys[peer], key)
+				continue
+			}
+		}
+		localKeys = append(localKeys, key)
+	}
+
+	var wg sync.WaitGroup
+	for peer, ks := range peerKeys {
+		wg.Add(1)
+		go func(peer PeerClient, ks []string) {
+			defer wg.Done()
+			g.getManyFromPeer(ctx, peer, ks, setResult)
+		}(peer, ks)
+	}
+	if len(localKeys) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.getManyLocally(ctx, localKeys, setResult)
+		}()
+	}
+	wg.Wait()
+
+	if IsMetricsEnabled() {
+		status := "success"
+		for _, r := range results {
+			if r.Err != nil && !errors.Is(r.Err, ErrNotFound) {
+				status = "error"
+				break
+			}
+		}
+		GetMetrics().RecordRequest("get_many", status)
+		GetMetrics().RecordDuration("get_many", status, time.Since(start).Seconds())
+	}
+	return results
+}
+
+// getManyFromPeer 向主节点发送一次批量请求，请求整体失败或单个 key 出错时退回到逐个 load
+func (g *Group) getManyFromPeer(ctx context.Context, peer PeerClient, keys []string, setResult func(string, ByteView, error)) {
+	var fallback []string
+	values, err := peer.BatchGet(ctx, g.name, keys)
+	if err != nil || len(values) != len(keys) {
+		fallback = keys
+	} else {
+		for i, key := range keys {
+			switch r := values[i]; {
+			case r.Err == nil:
+				if IsMetricsEnabled() {
+					GetMetrics().RecordHit("remote")
+				}
+				g.AddBloomKeys(key)
+				setResult(key, r.Value, nil)
+			case errors.Is(r.Err, ErrNotFound):
+				g.setNegative(key)
+				setResult(key, ByteView{}, ErrNotFound)
+			default:
+				fallback = append(fallback, key)
+			}
+		}
+	}
+	for _, key := range fallback {
+		value, err := g.load(ctx, key)
+		setResult(key, value, err)
+	}
+}
+
+// getManyLocally 加载本节点负责的 key，getter 实现了 BatchGetter 时一次性加载
+// 批量加载与 load 共用 singleflight 的 key：正在被其他请求加载的 key 等待其结果，
+// 批量加载期间对同一 key 的 Get 也会等待批量结果。getter 同时实现了 TTLGetter 时逐个加载，
+// 因为 BatchGetter 无法返回每个 key 的过期时间
+func (g *Group) getManyLocally(ctx context.Context, keys []string, setResult func(string, ByteView, error)) {
+	bg, ok := g.getter.(BatchGetter)
+	if _, perKeyTTL := g.getter.(TTLGetter); !ok || perKeyTTL {
+		g.loadEach(ctx, keys, setResult)
+		return
+	}
+
+	var batch, waiting []string
+	dones := make(map[string]func(interface{}, error), len(keys))
+	for _, key := range keys {
+		if done, ok := g.loader.TryAcquire(key); ok {
+			batch = append(batch, key)
+			dones[key] = done
+		} else {
+			waiting = append(waiting, key)
+		}
+	}
+	finish := func(key string, value ByteView, err error) {
+		dones[key](value, err)
+		delete(dones, key)
+		setResult(key, value, err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		g.loadEach(ctx, waiting, setResult)
+	}()
+	defer wg.Wait()
+	// BatchGetter panic 时仍然完成所有登记的 key，否则等待这些 key 的 Get 会一直阻塞
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("batch getter panic: %v", r)
+			for key := range dones {
+				finish(key, ByteView{}, err)
+			}
+		}
+	}()
+	if len(batch) == 0 {
+		return
+	}
+
+	values, err := bg.GetMany(ctx, batch)
+	if err != nil {
+		for _, key := range batch {
+			finish(key, ByteView{}, err)
+		}
+		return
+	}
+	for _, key := range batch {
+		bytes, ok := values[key]
+		if !ok {
+			g.setNegative(key)
+			finish(key, ByteView{}, ErrNotFound)
+			continue
+		}
+		// 克隆一份数据，避免外部数据源持有对底层数组的引用，与逐个加载一样按配置压缩
+		value := g.mainCache.compressor.compress(ByteView{b: cloneBytes(bytes)})
+		if g.ttl > 0 {
+			value.expire = time.Now().Add(g.ttl)
+		}
+		g.set(key, value, 0)
+		finish(key, value, nil)
+	}
+}
+
+// loadEach 并发地逐个 load
+func (g *Group) loadEach(ctx context.Context, keys []string, setResult func(string, ByteView, error)) {
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			value, err := g.load(ctx, key)
+			setResult(key, value, err)
+		}(key)
+	}
+	wg.Wait()
+}
@@ -2,7 +2,9 @@ package distcache
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math/rand"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -443,3 +445,161 @@ func TestPerformanceReport(t *testing.T) {
 		})
 	}
 }
+
+// ============================================
+// 优化7: 分片数与分片哈希
+// 原始方案：每次调用 fnv.New32 分配哈希器并取模
+// 优化方案：内联 FNV-1a / maphash，分片数为 2 的幂时用位与定位
+// ============================================
+
+// fnvAllocHash 是原始的分片哈希，每次调用都会分配哈希器和 []byte
+func fnvAllocHash(key string) uint32 {
+	h := fnv.New32()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+func BenchmarkCache_HashFunc(b *testing.B) {
+	hashes := []struct {
+		name string
+		fn   HashFunc
+	}{
+		{"FNVAlloc", fnvAllocHash},
+		{"FNV1a", FNV1aHash},
+		{"Maphash", MaphashHash},
+	}
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user:profile:%d", i)
+	}
+
+	for _, h := range hashes {
+		b.Run(h.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var sum uint32
+			for i := 0; i < b.N; i++ {
+				sum += h.fn(keys[i%len(keys)])
+			}
+			_ = sum
+		})
+	}
+}
+
+func BenchmarkCache_ShardCount(b *testing.B) {
+	for _, shardCount := range []int{16, 64, 256, 1024} {
+		for _, h := range []struct {
+			name string
+			fn   HashFunc
+		}{{"FNV1a", FNV1aHash}, {"Maphash", MaphashHash}} {
+			b.Run(fmt.Sprintf("Shards-%d-%s", shardCount, h.name), func(b *testing.B) {
+				cache := newCacheWithOptions(cacheOptions{
+					cacheBytes:    2 << 20,
+					hotThreshold:  DefaultHotKeyThreshold,
+					decayInterval: DefaultDecayInterval,
+					shardCount:    shardCount,
+					hash:          h.fn,
+				})
+				defer cache.close()
+
+				keys := make([]string, 1000)
+				for i := range keys {
+					keys[i] = fmt.Sprintf("key-%d", i)
+					cache.add(keys[i], ByteView{b: []byte(fmt.Sprintf("value-%d", i))})
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := rand.Intn(len(keys))
+					for pb.Next() {
+						key := keys[i%len(keys)]
+						if i%5 == 0 {
+							cache.add(key, ByteView{b: []byte("value")})
+						} else {
+							cache.get(key)
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
+
+// ============================================
+// 优化8: 分片存储后端
+// 原始方案：container/list + map[string]*list.Element，每个条目都是若干个需要 GC 扫描的对象
+// 优化方案：SlabPolicy，条目编码后写入连续的字节 slab，索引为 map[uint64]int，不含指针
+// ============================================
+
+var storagePolicies = []struct {
+	name   string
+	policy PolicyFactory
+}{
+	{"List", LRUPolicy},
+	{"Slab", SlabPolicy},
+}
+
+// fillPolicy 写入 n 个小条目，返回使用的 key
+func fillPolicy(p EvictionPolicy, n int) []string {
+	keys := make([]string, n)
+	now := time.Now()
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%08d", i)
+		p.Add(keys[i], &cacheEntry{value: ByteView{b: []byte(fmt.Sprintf("value-%024d", i))}, inserted: now, lastAccess: now})
+	}
+	return keys
+}
+
+func BenchmarkStorage_Get(b *testing.B) {
+	for _, s := range storagePolicies {
+		b.Run(s.name, func(b *testing.B) {
+			p := s.policy(0, nil)
+			keys := fillPolicy(p, 100000)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Get(keys[i%len(keys)])
+			}
+		})
+	}
+}
+
+func BenchmarkStorage_Add(b *testing.B) {
+	for _, s := range storagePolicies {
+		b.Run(s.name, func(b *testing.B) {
+			// 容量只够放一部分条目，写入同时触发淘汰
+			p := s.policy(4<<20, nil)
+			keys := make([]string, 200000)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("key-%08d", i)
+			}
+			value := ByteView{b: make([]byte, 32)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Add(keys[i%len(keys)], &cacheEntry{value: value})
+			}
+		})
+	}
+}
+
+// BenchmarkStorage_GC 测量缓存中有大量小条目时一次完整 GC 的耗时
+func BenchmarkStorage_GC(b *testing.B) {
+	for _, s := range storagePolicies {
+		b.Run(s.name, func(b *testing.B) {
+			p := s.policy(0, nil)
+			fillPolicy(p, 1000000)
+			runtime.GC()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+			}
+			b.StopTimer()
+			var ms runtime.MemStats
+			runtime.ReadMemStats(&ms)
+			b.ReportMetric(float64(ms.HeapObjects), "heap-objects")
+			runtime.KeepAlive(p)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package bloomfilter
 
 import (
 	"hash/fnv"
+	"math"
 	"sync"
 )
 
@@ -20,6 +21,22 @@ func NewBloomFilter(size uint, hashes uint) *BloomFilter {
     }
 }
 
+// NewBloomFilterWithEstimates 根据预期元素个数 n 和期望假阳性率 p 计算位数组长度和哈希函数个数
+func NewBloomFilterWithEstimates(n uint, p float64) *BloomFilter {
+    if n == 0 {
+        n = 1
+    }
+    if p <= 0 || p >= 1 {
+        p = 0.01
+    }
+    m := uint(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+    k := uint(math.Round(float64(m) / float64(n) * math.Ln2))
+    if k == 0 {
+        k = 1
+    }
+    return NewBloomFilter(m, k)
+}
+
 func (bf *BloomFilter) Add(key string) {
     bf.mutex.Lock()
     defer bf.mutex.Unlock()
@@ -41,6 +58,15 @@ func (bf *BloomFilter) Test(key string) bool {
     return true
 }
 
+// Reset 清空所有位
+func (bf *BloomFilter) Reset() {
+    bf.mutex.Lock()
+    defer bf.mutex.Unlock()
+    for i := range bf.bits {
+        bf.bits[i] = 0
+    }
+}
+
 func (bf *BloomFilter) hash(key string, i uint) uint {
     // 使用双重哈希避免聚集
     h1 := fnv.New32a()
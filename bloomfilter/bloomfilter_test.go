@@ -73,6 +73,31 @@ func TestBloomFilter_FalsePositiveRate(t *testing.T) {
 	}
 }
 
+// 按预期元素个数和假阳性率创建
+func TestBloomFilter_WithEstimates(t *testing.T) {
+	bf := NewBloomFilterWithEstimates(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		bf.Add(fmt.Sprintf("added_key_%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !bf.Test(fmt.Sprintf("added_key_%d", i)) {
+			t.Fatalf("Expected key added_key_%d to be found", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if bf.Test(fmt.Sprintf("test_key_%d", i)) {
+			falsePositives++
+		}
+	}
+	fpRate := float64(falsePositives) / 10000 * 100
+	t.Logf("m: %d, k: %d, FP Rate: %.2f%%", bf.m, bf.k, fpRate)
+	if fpRate > 3.0 {
+		t.Errorf("False positive rate too high: %.2f%% (expected around 1%%)", fpRate)
+	}
+}
+
 // 并发安全测试
 func TestBloomFilter_ConcurrentAccess(t *testing.T) {
 	bf := NewBloomFilter(50000, 5) // 使用更大的过滤器
@@ -282,3 +307,15 @@ func TestBloomFilter_RealWorldScenario(t *testing.T) {
 		t.Errorf("False positive rate too high for real world scenario: %.2f%%", fpRate)
 	}
 }
+
+func TestBloomFilter_Reset(t *testing.T) {
+	bf := NewBloomFilterWithEstimates(100, 0.01)
+	bf.Add("key")
+	if !bf.Test("key") {
+		t.Fatal("key should exist before reset")
+	}
+	bf.Reset()
+	if bf.Test("key") {
+		t.Error("key should not exist after reset")
+	}
+}
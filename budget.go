@@ -0,0 +1,152 @@
+package distcache
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRebalanceInterval 共享预算默认的重新分配周期
+const DefaultRebalanceInterval = 10 * time.Second
+
+// BudgetMode 决定共享预算如何在 Group 之间分配
+type BudgetMode int
+
+const (
+	// BudgetByWeight 按权重比例分配
+	BudgetByWeight BudgetMode = iota
+	// BudgetByHitValue 按权重乘以近期命中次数分配，命中多的 Group 分到更多内存
+	BudgetByHitValue
+)
+
+// CacheBudget 是多个 Group 共享的内存预算，通过 WithCacheBudget 加入
+// 每个 Group 的容量由预算定期重新分配，所有 Group 的容量之和等于 totalBytes
+type CacheBudget struct {
+	totalBytes int64
+	mode       BudgetMode
+	interval   time.Duration
+
+	mu      sync.Mutex
+	members map[*cache]*budgetMember
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+type budgetMember struct {
+	weight float64
+	value  float64 // 命中次数的指数平均，每个周期减半后加上新的命中次数
+}
+
+// NewCacheBudget 创建共享预算并启动后台重新分配，interval <= 0 时使用 DefaultRebalanceInterval
+func NewCacheBudget(totalBytes int64, mode BudgetMode, interval time.Duration) *CacheBudget {
+	if interval <= 0 {
+		interval = DefaultRebalanceInterval
+	}
+	b := &CacheBudget{
+		totalBytes: totalBytes,
+		mode:       mode,
+		interval:   interval,
+		members:    make(map[*cache]*budgetMember),
+		stopCh:     make(chan struct{}),
+	}
+	go b.periodicRebalance()
+	return b
+}
+
+// Stop 停止后台重新分配，已分配的容量保持不变，可以重复调用
+func (b *CacheBudget) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+}
+
+// Rebalance 立即重新分配各 Group 的容量
+func (b *CacheBudget) Rebalance() {
+	b.mu.Lock()
+	changed := b.rebalanceLocked()
+	b.mu.Unlock()
+	b.apply(changed)
+}
+
+// join 加入预算并立即重新分配，weight <= 0 时按 1 处理
+func (b *CacheBudget) join(c *cache, weight float64) {
+	if weight <= 0 {
+		weight = 1
+	}
+	b.mu.Lock()
+	c.budget = b
+	b.members[c] = &budgetMember{weight: weight}
+	changed := b.rebalanceLocked()
+	b.mu.Unlock()
+	b.apply(changed)
+}
+
+// leave 退出预算，释放的容量分给其余 Group
+func (b *CacheBudget) leave(c *cache) {
+	b.mu.Lock()
+	if _, ok := b.members[c]; !ok {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.members, c)
+	if IsMetricsEnabled() && c.groupName != "" {
+		GetMetrics().BudgetShare.DeleteLabelValues(c.groupName)
+	}
+	changed := b.rebalanceLocked()
+	b.mu.Unlock()
+	b.apply(changed)
+}
+
+// rebalanceLocked 计算并记录各 Group 的新容量，返回容量发生变化的缓存，需持有 mu
+// 容量变化后的淘汰由 apply 在解锁后进行：淘汰会调用回调和写磁盘，不能阻塞其他 Group，回调中也可能加入或退出预算
+func (b *CacheBudget) rebalanceLocked() []*cache {
+	if len(b.members) == 0 {
+		return nil
+	}
+	scores := make(map[*cache]float64, len(b.members))
+	var sum float64
+	for c, m := range b.members {
+		m.value = m.value/2 + float64(c.hits.Swap(0))
+		score := m.weight
+		if b.mode == BudgetByHitValue {
+			score *= 1 + m.value
+		}
+		scores[c] = score
+		sum += score
+	}
+	var changed []*cache
+	for c, score := range scores {
+		share := int64(float64(b.totalBytes) * score / sum)
+		if share < 1 {
+			// 0 表示不限制容量，至少保留 1 字节
+			share = 1
+		}
+		if c.cacheBytes.Swap(share) != share {
+			changed = append(changed, c)
+		}
+		if IsMetricsEnabled() && c.groupName != "" {
+			GetMetrics().SetBudgetShare(c.groupName, share)
+		}
+	}
+	return changed
+}
+
+// apply 让容量变化生效，不能持有 mu 调用
+func (b *CacheBudget) apply(changed []*cache) {
+	for _, c := range changed {
+		c.setCacheBytes(c.cacheBytes.Load())
+	}
+}
+
+func (b *CacheBudget) periodicRebalance() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Rebalance()
+		case <-b.stopCh:
+			return
+		}
+	}
+}
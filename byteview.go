@@ -1,20 +1,93 @@
 package distcache
 
+import (
+	"time"
+)
+
 // ByteView 将lru包中的Value接口实现为只读的字节切片，防止外部修改
 type ByteView struct{
 	b []byte
+	// 过期时间，零值表示永不过期
+	expire time.Time
+	// 负缓存标记，表示 key 在数据源中不存在
+	negative bool
+	// b 的压缩方式，读取时按需解压
+	codec Compression
+	// 解压后的长度，只在值被压缩时有效
+	n int
 }
 
+// Len 返回解压后的长度，不需要解压
 func (v ByteView) Len() int{
-	return len(v.b)
+	if v.codec == CompressionNone {
+		return len(v.b)
+	}
+	return v.n
 }
 
+// ByteSlice 返回解压后数据的拷贝，解压失败时返回 nil，需要区分时使用 Decode
 func (v ByteView) ByteSlice() []byte{
-	return cloneBytes(v.b)
+	if v.codec == CompressionNone {
+		return cloneBytes(v.b)
+	}
+	// 解压结果是新分配的，不需要再拷贝
+	b, _ := v.raw()
+	return b
 }
 
+// String 返回解压后的数据，解压失败时返回空字符串，需要区分时使用 Decode
 func (v ByteView) String()string{
-	return string(v.b)
+	b, _ := v.raw()
+	return string(b)
+}
+
+// Decode 与 ByteSlice 相同，但在解压失败时返回错误
+func (v ByteView) Decode() ([]byte, error){
+	if v.codec == CompressionNone {
+		return cloneBytes(v.b), nil
+	}
+	return v.raw()
+}
+
+// raw 返回解压后的数据，未压缩时直接返回底层切片，调用方不能修改
+func (v ByteView) raw() ([]byte, error) {
+	if v.codec == CompressionNone {
+		return v.b, nil
+	}
+	return decompressBytes(v.codec, v.b, v.n)
+}
+
+// compressedView 用从节点、磁盘或快照收到的数据构造 ByteView，rawSize 是解压后的长度，
+// 为 0 表示未知（如旧版本节点发来的数据），此时解压一次得到，数据损坏时返回错误
+func compressedView(b []byte, codec Compression, rawSize int) (ByteView, error) {
+	v := ByteView{b: b, codec: codec}
+	if codec == CompressionNone {
+		return v, nil
+	}
+	if rawSize <= 0 {
+		raw, err := decompressBytes(codec, b, 0)
+		if err != nil {
+			return ByteView{}, err
+		}
+		rawSize = len(raw)
+	}
+	v.n = rawSize
+	return v, nil
+}
+
+// size 返回实际占用的字节数，值被压缩时是压缩后的大小
+func (v ByteView) size() int {
+	return len(v.b)
+}
+
+// Expire 返回过期时间，零值表示永不过期
+func (v ByteView) Expire() time.Time {
+	return v.expire
+}
+
+// expired 判断在 now 时刻是否已过期
+func (v ByteView) expired(now time.Time) bool {
+	return !v.expire.IsZero() && !now.Before(v.expire)
 }
 
 func cloneBytes(b []byte)[]byte{
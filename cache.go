@@ -1,82 +1,351 @@
 package distcache
 
 import (
-	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/simplely77/distcache/diskcache"
 	"github.com/simplely77/distcache/lru"
 )
 
-const shardCount = 256
+// DefaultShardCount 默认的缓存分片数
+const DefaultShardCount = 256
 
 type cacheShard struct {
-	mu  sync.Mutex
-	lru *lru.Cache
+	mu     sync.Mutex
+	policy EvictionPolicy
+	// reason 是当前操作触发淘汰回调时使用的原因，默认为容量淘汰
+	reason EvictReason
+	// evicted 收集持锁期间的淘汰事件，解锁后再统一通知，避免回调里访问缓存造成死锁
+	evicted []evictedEntry
 }
 
+// remove 以指定原因删除条目，需持有分片锁
+func (s *cacheShard) remove(key string, reason EvictReason) {
+	s.reason = reason
+	s.policy.Remove(key)
+	s.reason = EvictCapacity
+}
+
+// cacheEntry 是分片中保存的条目，附带用于检查的元数据，元数据在分片锁保护下更新
+// 热点层的命中不经过分片，不计入 lastAccess 和 accesses
+type cacheEntry struct {
+	value      ByteView
+	inserted   time.Time
+	lastAccess time.Time
+	accesses   uint64
+	// onDisk 表示条目是从磁盘层读回的，磁盘上仍保留着同样的值
+	onDisk bool
+}
+
+func (e *cacheEntry) Len() int {
+	return e.value.size()
+}
+
+// drain 取出已收集的淘汰事件，需持有分片锁
+func (s *cacheShard) drain() []evictedEntry {
+	evicted := s.evicted
+	s.evicted = nil
+	return evicted
+}
+
+// drain 取出分片收集的淘汰事件，并把容量淘汰的条目写入磁盘层，需持有分片锁
+// 在分片锁内溢出，解锁后同一 key 的写入和删除一定排在溢出之后，不会在磁盘上留下旧值
+func (c *cache) drain(shard *cacheShard) []evictedEntry {
+	evicted := shard.drain()
+	for _, e := range evicted {
+		if e.reason == EvictCapacity && !e.onDisk && !e.value.negative {
+			c.spillToDisk(e.key, e.value)
+		}
+	}
+	return evicted
+}
+
+// cache 的容量按整个缓存计算：各分片的淘汰策略只在单个分片超过总容量时才自行淘汰，
+// 总字节数超过 cacheBytes 时由 evict 从抽样到的分片中淘汰，避免 key 分布不均时部分分片空闲、部分分片频繁淘汰
 type cache struct {
-	shards      [shardCount]*cacheShard
-	cacheBytes  int64
+	shards      []*cacheShard
+	shardMask   uint32 // 分片数是 2 的幂，用位与代替取模
+	hash        HashFunc
+	cacheBytes  atomic.Int64 // 总容量，共享预算时会被定期调整
+	nbytes      atomic.Int64 // 所有分片已使用的字节数
 	hotDetector *HotKeyDetector
-	groupName   string // 用于监控指标标签
+	groupName   string   // 用于监控指标标签
+	admission   *tinyLFU // 为 nil 时新条目无条件写入
+	budget      *CacheBudget
+	disk        *diskcache.Store // 可选的磁盘层，容量淘汰的条目写入磁盘
+	compressor  compressor
+	hits        atomic.Uint64 // 上次预算调整以来的命中次数
+	// 热点层字节上限是否按 cacheBytes 的比例计算，是则随容量调整
+	hotBytesAuto bool
+
+	evictMu sync.RWMutex
+	onEvict []func(key string, value ByteView, reason EvictReason)
+}
+
+// cacheOptions 是创建本地缓存的配置
+type cacheOptions struct {
+	cacheBytes    int64
+	hotThreshold  uint64
+	decayInterval time.Duration
+	shardCount    int // 向上取整为 2 的幂
+	hash          HashFunc
+	policy        PolicyFactory
+	admission     bool // 是否启用 TinyLFU 准入过滤
+	compressor    compressor
+	// 热点层容量，0 使用默认值，小于 0 表示不限制
+	hotMaxKeys  int
+	hotMaxBytes int64
 }
 
 func newCache(cacheBytes int64, hotThreshold uint64, decayInterval time.Duration) *cache {
+	return newCacheWithOptions(cacheOptions{
+		cacheBytes:    cacheBytes,
+		hotThreshold:  hotThreshold,
+		decayInterval: decayInterval,
+		shardCount:    DefaultShardCount,
+	})
+}
+
+func newCacheWithOptions(opts cacheOptions) *cache {
+	if opts.shardCount <= 0 {
+		opts.shardCount = DefaultShardCount
+	}
+	opts.shardCount = nextPowerOfTwo(opts.shardCount)
+	if opts.hash == nil {
+		opts.hash = FNV1aHash
+	}
+	if opts.policy == nil {
+		opts.policy = LRUPolicy
+	}
 	c := &cache{
-		cacheBytes:  cacheBytes,
-		hotDetector: NewHotKeyDetector(hotThreshold, decayInterval),
-		groupName:   "", // 需要后续设置
+		shards:     make([]*cacheShard, opts.shardCount),
+		shardMask:  uint32(opts.shardCount - 1),
+		hash:       opts.hash,
+		compressor: opts.compressor,
+		groupName:  "", // 需要后续设置
+	}
+	maxKeys, maxBytes := opts.hotMaxKeys, opts.hotMaxBytes
+	if maxKeys == 0 {
+		maxKeys = DefaultHotKeyMaxKeys
+	}
+	if maxBytes == 0 {
+		maxBytes = opts.cacheBytes / DefaultHotKeyBytesRatio
+		c.hotBytesAuto = true
 	}
+	c.hotDetector = newHotKeyDetector(opts.hotThreshold, opts.decayInterval, max(maxKeys, 0), max(maxBytes, 0), c.updateHotTierMetrics)
 
-	perBytes := cacheBytes / shardCount
-	for i := 0; i < shardCount; i++ {
-		c.shards[i] = &cacheShard{lru: lru.New(perBytes, nil)}
+	c.cacheBytes.Store(opts.cacheBytes)
+	for i := range c.shards {
+		shard := &cacheShard{}
+		shard.policy = opts.policy(opts.cacheBytes, func(key string, value lru.Value) {
+			entry := value.(*cacheEntry)
+			shard.evicted = append(shard.evicted, evictedEntry{key: key, value: entry.value, reason: shard.reason, onDisk: entry.onDisk})
+		})
+		c.shards[i] = shard
+	}
+	if opts.admission {
+		c.admission = newTinyLFU(DefaultAdmissionSamples)
 	}
 
 	return c
 }
 
 func (c *cache) getShard(key string) *cacheShard {
-	h := fnv.New32()
-	h.Write([]byte(key))
-	idx := h.Sum32() % shardCount
-	return c.shards[idx]
+	return c.shards[c.hash(key)&c.shardMask]
 }
 
-// add 将一个键值对添加到缓存中，就是在 lru 的基础上加了锁
+// addFlags 控制 add 在写入之外的附带行为
+type addFlags uint8
+
+const (
+	// addExplicit 表示调用方明确要求写入（Set、副本同步），跳过 TinyLFU 准入，写入不会被静默丢弃
+	addExplicit addFlags = 1 << iota
+	// addRestore 表示从快照恢复，不是一次真实的访问：跳过准入，也不计入热点和准入的频率统计
+	addRestore
+)
+
+// add 将一个键值对添加到缓存中，就是在淘汰策略的基础上加了锁
 func (c *cache) add(key string, value ByteView) {
+	c.addWith(key, value, 0)
+}
+
+// addWith 与 add 相同，flags 控制准入等附带行为
+func (c *cache) addWith(key string, value ByteView, flags addFlags) {
+	restore := flags&addRestore != 0
+	explicit := restore || flags&addExplicit != 0
+	// 准入要和全局淘汰实际会淘汰的条目比较，在持有目标分片锁之前选出它
+	victimShard, victim := c.admissionVictim(key, value, explicit)
 	shard := c.getShard(key)
 	shard.mu.Lock()
-	defer shard.mu.Unlock()
-	shard.lru.Add(key, value)
+	if shard.policy == nil || (!restore && !c.admit(shard, key, victim, explicit)) {
+		shard.mu.Unlock()
+		return
+	}
+	if old, ok := shard.policy.Peek(key); ok {
+		shard.evicted = append(shard.evicted, evictedEntry{key: key, value: old.(*cacheEntry).value, reason: EvictReplaced})
+	}
+	now := time.Now()
+	before := shard.policy.NBytes()
+	shard.policy.Add(key, &cacheEntry{value: value, inserted: now, lastAccess: now})
+	c.nbytes.Add(shard.policy.NBytes() - before)
+	// 已是热点的 key 先替换热点层中的旧值，再按频率统计
+	c.hotDetector.update(key, value)
+	if !restore {
+		c.hotDetector.RecordKey(key, value)
+	}
+	// 内存中有了新值，磁盘上的旧值就作废；磁盘操作在分片锁内进行，避免与同一 key 的溢出交错
+	c.deleteFromDisk(key)
+	evicted := c.drain(shard)
+	shard.mu.Unlock()
+
+	c.notifyEvicted(evicted)
+	c.evictFrom(victimShard)
+
+	// 更新缓存大小监控
+	c.updateCacheSizeMetrics()
+}
+
+// setCacheBytes 调整总容量，缩小时立即淘汰到新容量以内，按比例计算的热点层上限随之调整
+func (c *cache) setCacheBytes(n int64) {
+	c.cacheBytes.Store(n)
+	if c.hotBytesAuto {
+		c.hotDetector.setMaxBytes(n / DefaultHotKeyBytesRatio)
+	}
+	c.evict()
+	c.updateCacheSizeMetrics()
+}
+
+// charge 把分片之外但属于本缓存的内存（如类型化 Group 的解码结果）计入总字节数，
+// 增加时按需淘汰分片中的条目，不能持有分片锁调用
+func (c *cache) charge(delta int64) {
+	if delta == 0 {
+		return
+	}
+	c.nbytes.Add(delta)
+	if delta > 0 {
+		c.evict()
+	}
+	c.updateCacheSizeMetrics()
+}
+
+// recordHit 为共享预算统计命中次数
+func (c *cache) recordHit() {
+	if c.budget != nil {
+		c.hits.Add(1)
+	}
+}
+
+// evictionSamples 是全局淘汰时每轮随机抽样的分片数
+const evictionSamples = 5
+
+// evict 在总字节数超过容量时，反复从抽样分片中占用最多的一个淘汰条目，不能持有分片锁调用
+func (c *cache) evict() {
+	c.evictFrom(nil)
+}
 
-	c.hotDetector.RecordKey(key, value)
+// evictFrom 与 evict 相同，但第一个条目从 first 中淘汰（准入时比较过的淘汰候选所在的分片），first 可以为 nil
+func (c *cache) evictFrom(first *cacheShard) {
+	for max := c.cacheBytes.Load(); max > 0 && c.nbytes.Load() > max; max = c.cacheBytes.Load() {
+		shard := first
+		if shard == nil {
+			shard = c.largestShard()
+		}
+		first = nil
+		if shard == nil {
+			return
+		}
+		shard.mu.Lock()
+		var evicted []evictedEntry
+		if shard.policy != nil {
+			before := shard.policy.NBytes()
+			shard.policy.RemoveOldest()
+			c.nbytes.Add(shard.policy.NBytes() - before)
+			evicted = c.drain(shard)
+		}
+		shard.mu.Unlock()
+		c.notifyEvicted(evicted)
+	}
+}
 
-	// 更新缓存大小监控（异步，避免阻塞）
-	go c.updateCacheSizeMetrics()
+// largestShard 随机抽样几个分片并返回占用最多的一个，抽样的分片都为空时退回到遍历所有分片
+func (c *cache) largestShard() *cacheShard {
+	var best *cacheShard
+	var bestBytes int64
+	consider := func(shard *cacheShard) {
+		shard.mu.Lock()
+		if shard.policy != nil && shard.policy.NBytes() > bestBytes {
+			best, bestBytes = shard, shard.policy.NBytes()
+		}
+		shard.mu.Unlock()
+	}
+	if len(c.shards) > evictionSamples {
+		for i := 0; i < evictionSamples; i++ {
+			consider(c.shards[rand.IntN(len(c.shards))])
+		}
+		if best != nil {
+			return best
+		}
+	}
+	for _, shard := range c.shards {
+		consider(shard)
+	}
+	return best
+}
+
+// get 从缓存中获取一个键对应的值，内存未命中时再查磁盘层
+func (c *cache) get(key string) (ByteView, bool) {
+	if v, ok := c.getMemory(key); ok {
+		return v, true
+	}
+	return c.getFromDisk(key)
 }
 
-// get 从缓存中获取一个键对应的值
-func (c *cache) get(key string) (value ByteView, ok bool) {
+// getMemory 从热点层和分片中获取一个键对应的值
+func (c *cache) getMemory(key string) (value ByteView, ok bool) {
 	// 先检查是否为热点key
 	if v, found := c.hotDetector.GetHot(key); found {
 		if IsMetricsEnabled() {
 			GetMetrics().RecordHit("hot")
 			GetMetrics().RecordHotKeyHit()
 		}
+		c.recordHit()
 		return v, true
 	}
 
 	shard := c.getShard(key)
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
-	if shard.lru == nil {
+	if shard.policy == nil {
 		return
 	}
-	if v, found := shard.lru.Get(key); found {
-		value = v.(ByteView)
+	if v, found := shard.policy.Get(key); found {
+		entry := v.(*cacheEntry)
+		value = entry.value
+		now := time.Now()
+		// 过期的条目视为未命中，直接从分片和热点中清除
+		if value.expired(now) {
+			before := shard.policy.NBytes()
+			shard.remove(key, EvictExpired)
+			c.nbytes.Add(shard.policy.NBytes() - before)
+			c.hotDetector.remove(key)
+			// 通知放到解锁之后
+			evicted := c.drain(shard)
+			defer c.notifyEvicted(evicted)
+			return ByteView{}, false
+		}
 		ok = true
+		entry.lastAccess = now
+		entry.accesses++
+		if c.admission != nil {
+			c.admission.record(key)
+		}
+		c.recordHit()
 		// 同步记录热点，确保高并发下准确统计
 		c.hotDetector.RecordKey(key, value)
 		if IsMetricsEnabled() {
@@ -86,37 +355,255 @@ func (c *cache) get(key string) (value ByteView, ok bool) {
 	return
 }
 
+// peek 查找条目但不影响淘汰顺序、热点统计、准入统计和命中指标，过期条目视为不存在
+// 分片中没有时再查热点层，热点层的条目仍然会被 get 返回
+func (c *cache) peek(key string) (ByteView, bool) {
+	if entry, ok := c.peekEntry(key); ok {
+		return entry.value, true
+	}
+	if v, ok := c.hotDetector.PeekHot(key); ok && !v.expired(time.Now()) {
+		return v, true
+	}
+	return ByteView{}, false
+}
+
+// peekEntry 返回分片中条目的一份拷贝，不影响淘汰顺序
+func (c *cache) peekEntry(key string) (cacheEntry, bool) {
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.policy == nil {
+		return cacheEntry{}, false
+	}
+	v, ok := shard.policy.Peek(key)
+	if !ok {
+		return cacheEntry{}, false
+	}
+	entry := *v.(*cacheEntry)
+	if entry.value.expired(time.Now()) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// rangeEntries 逐个分片遍历未过期的条目：持锁复制一个分片的条目后立即解锁再回调，
+// 同一时刻最多持有一个分片锁，回调中可以访问缓存；遍历期间的写入不保证可见
+func (c *cache) rangeEntries(fn func(key string, entry cacheEntry) bool) {
+	type item struct {
+		key   string
+		entry cacheEntry
+	}
+	var items []item
+	for _, shard := range c.shards {
+		items = items[:0]
+		now := time.Now()
+		shard.mu.Lock()
+		if shard.policy != nil {
+			shard.policy.Range(func(key string, v lru.Value) bool {
+				if e := v.(*cacheEntry); !e.value.expired(now) {
+					items = append(items, item{key, *e})
+				}
+				return true
+			})
+		}
+		shard.mu.Unlock()
+		for _, it := range items {
+			if !fn(it.key, it.entry) {
+				return
+			}
+		}
+	}
+}
+
+// borrowRanger 是淘汰策略可选实现的接口，遍历时传给 fn 的值引用策略内部的存储，不复制，
+// fn 不能保留它；SlabPolicy 的 Range 需要解码并复制值，只读取 key 和元数据时用它代替
+type borrowRanger interface {
+	rangeBorrowed(fn func(key string, value lru.Value) bool)
+}
+
+// scanKeys 从第 shard 个分片开始，按分片顺序列出未过期的、以 prefix 开头的 key，同一分片内按字典序，
+// after 非空时跳过第一个分片中不大于 after 的 key，最多返回 limit 个
+// 同一时刻只持有一个分片锁，只复制 key；还有剩余时返回下一页开始的分片和该分片中已返回的最后一个 key，否则 next 为 -1
+func (c *cache) scanKeys(prefix string, shard int, after string, limit int) (keys []string, next int, last string) {
+	var shardKeys []string
+	for i := shard; i >= 0 && i < len(c.shards); i++ {
+		shardKeys = shardKeys[:0]
+		now := time.Now()
+		visit := func(key string, v lru.Value) bool {
+			e := v.(*cacheEntry)
+			if strings.HasPrefix(key, prefix) && (i != shard || after == "" || key > after) &&
+				!e.value.negative && !e.value.expired(now) {
+				shardKeys = append(shardKeys, key)
+			}
+			return true
+		}
+		s := c.shards[i]
+		s.mu.Lock()
+		if br, ok := s.policy.(borrowRanger); ok {
+			br.rangeBorrowed(visit)
+		} else if s.policy != nil {
+			s.policy.Range(visit)
+		}
+		s.mu.Unlock()
+		sort.Strings(shardKeys)
+		for j, key := range shardKeys {
+			if len(keys) == limit {
+				if j == 0 {
+					return keys, i, ""
+				}
+				return keys, i, shardKeys[j-1]
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, -1, ""
+}
+
+// isHot 判断 key 是否在热点层
+func (c *cache) isHot(key string) bool {
+	_, ok := c.hotDetector.hotKeys.Load(key)
+	return ok
+}
+
+// admissionVictim 在写入会超出容量时，选出全局淘汰接下来会淘汰的分片及其淘汰候选，不能持有分片锁调用
+// 不需要准入检查时返回 nil
+func (c *cache) admissionVictim(key string, value ByteView, explicit bool) (*cacheShard, string) {
+	if c.admission == nil || explicit {
+		return nil, ""
+	}
+	max := c.cacheBytes.Load()
+	if max <= 0 || c.nbytes.Load()+int64(len(key)+value.size()) <= max {
+		return nil, ""
+	}
+	shard := c.largestShard()
+	if shard == nil {
+		return nil, ""
+	}
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if shard.policy == nil {
+		return nil, ""
+	}
+	victim, ok := shard.policy.Victim()
+	if !ok {
+		return nil, ""
+	}
+	return shard, victim
+}
+
+// admit 在缓存已满且 key 是新条目时，比较它和淘汰候选 victim 的访问频率决定是否写入，需持有分片锁
+// victim 为空表示不需要检查；explicit 为 true 时只记录访问，总是允许写入
+func (c *cache) admit(shard *cacheShard, key string, victim string, explicit bool) bool {
+	if c.admission == nil {
+		return true
+	}
+	c.admission.record(key)
+	if explicit || victim == "" || victim == key {
+		return true
+	}
+	if _, exists := shard.policy.Peek(key); exists {
+		return true
+	}
+	admitted := c.admission.admit(key, victim)
+	if IsMetricsEnabled() {
+		if admitted {
+			GetMetrics().RecordAdmission("admitted")
+		} else {
+			GetMetrics().RecordAdmission("rejected")
+		}
+	}
+	return admitted
+}
+
 func (c *cache) delete(key string) {
 	// 删除分片
 	shard := c.getShard(key)
 	shard.mu.Lock()
-	if shard.lru != nil {
-		shard.lru.Remove(key)
+	var evicted []evictedEntry
+	if shard.policy != nil {
+		before := shard.policy.NBytes()
+		shard.remove(key, EvictDeleted)
+		c.nbytes.Add(shard.policy.NBytes() - before)
+		c.deleteFromDisk(key)
+		evicted = c.drain(shard)
 	}
 	shard.mu.Unlock()
+	c.notifyEvicted(evicted)
 
 	// 删除热点
-	c.hotDetector.hotKeys.Delete(key)
+	c.hotDetector.remove(key)
 
 	// 更新缓存大小监控
 	c.updateCacheSizeMetrics()
 }
 
-// updateCacheSizeMetrics 更新缓存大小监控指标
-func (c *cache) updateCacheSizeMetrics() {
-	if !IsMetricsEnabled() || c.groupName == "" {
+// addEvictHook 注册淘汰回调
+func (c *cache) addEvictHook(fn func(key string, value ByteView, reason EvictReason)) {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+	c.onEvict = append(c.onEvict, fn)
+}
+
+// notifyEvicted 记录淘汰指标并调用回调，不能持有分片锁调用
+// 负缓存条目只是内部标记，只计入指标，不通知回调
+func (c *cache) notifyEvicted(evicted []evictedEntry) {
+	if len(evicted) == 0 {
 		return
 	}
+	c.evictMu.RLock()
+	hooks := c.onEvict
+	c.evictMu.RUnlock()
+	for _, e := range evicted {
+		if IsMetricsEnabled() && c.groupName != "" {
+			GetMetrics().RecordEviction(c.groupName, e.reason.String())
+		}
+		if e.value.negative {
+			continue
+		}
+		for _, fn := range hooks {
+			fn(e.key, e.value, e.reason)
+		}
+	}
+}
 
-	var totalBytes int64
-	for i := 0; i < shardCount; i++ {
-		shard := c.shards[i]
+// close 停止热点检测器并释放所有分片，之后的 add 和 get 都不再生效
+func (c *cache) close() {
+	if c.budget != nil {
+		c.budget.leave(c)
+	}
+	if c.disk != nil {
+		c.disk.Close()
+	}
+	c.hotDetector.Stop()
+	for _, shard := range c.shards {
 		shard.mu.Lock()
-		if shard.lru != nil {
-			totalBytes += shard.lru.NBytes()
-		}
+		shard.policy = nil
 		shard.mu.Unlock()
 	}
+	c.nbytes.Store(0)
+	c.hotDetector.clear()
+	if IsMetricsEnabled() && c.groupName != "" {
+		GetMetrics().CacheSize.DeleteLabelValues(c.groupName)
+		GetMetrics().EvictionsTotal.DeletePartialMatch(prometheus.Labels{"group": c.groupName})
+		GetMetrics().DiskSize.DeleteLabelValues(c.groupName)
+		GetMetrics().HotTierKeys.DeleteLabelValues(c.groupName)
+		GetMetrics().HotTierBytes.DeleteLabelValues(c.groupName)
+	}
+}
+
+// updateCacheSizeMetrics 更新缓存大小监控指标
+func (c *cache) updateCacheSizeMetrics() {
+	if !IsMetricsEnabled() || c.groupName == "" {
+		return
+	}
 
-	GetMetrics().SetCacheSize(c.groupName, totalBytes)
+	GetMetrics().SetCacheSize(c.groupName, c.nbytes.Load())
+}
+
+// updateHotTierMetrics 更新热点层大小监控指标
+func (c *cache) updateHotTierMetrics(keys int, bytes int64) {
+	if !IsMetricsEnabled() || c.groupName == "" {
+		return
+	}
+	GetMetrics().SetHotTierSize(c.groupName, keys, bytes)
 }
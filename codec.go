@@ -0,0 +1,62 @@
+package distcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec 负责 TypedGroup 中值与字节之间的转换
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSONCodec 使用 encoding/json 编解码
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobCodec 使用 encoding/gob 编解码
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Marshal(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// ProtoCodec 使用 protobuf 编解码，T 为生成的消息指针类型，例如 *pb.GetRequest
+type ProtoCodec[T proto.Message] struct{}
+
+func (ProtoCodec[T]) Marshal(v T) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+func (ProtoCodec[T]) Unmarshal(data []byte) (T, error) {
+	// 生成代码的 ProtoReflect 允许 nil 接收者，可以借此创建新的消息实例
+	var zero T
+	v := zero.ProtoReflect().New().Interface().(T)
+	if err := proto.Unmarshal(data, v); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
@@ -0,0 +1,137 @@
+package distcache
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression 是缓存值的压缩方式，会随 ByteView 一起保存并在节点间传输
+type Compression uint8
+
+const (
+	// CompressionNone 不压缩
+	CompressionNone Compression = iota
+	// CompressionGzip 使用 gzip 压缩
+	CompressionGzip
+	// CompressionFlate 使用 DEFLATE 压缩，没有 gzip 的头部和校验，开销更小
+	CompressionFlate
+)
+
+// DefaultCompressionThreshold 是启用压缩时的默认阈值，小于该大小的值不压缩
+const DefaultCompressionThreshold = 1 << 10
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionFlate:
+		return "flate"
+	default:
+		return fmt.Sprintf("compression(%d)", uint8(c))
+	}
+}
+
+// compressor 是 Group 的压缩配置
+type compressor struct {
+	codec     Compression
+	threshold int
+}
+
+// compress 压缩达到阈值的值，压缩后没有变小或值已经压缩过时原样返回
+func (c compressor) compress(v ByteView) ByteView {
+	if c.codec == CompressionNone || v.codec != CompressionNone || v.negative || len(v.b) < c.threshold {
+		return v
+	}
+	out, err := compressBytes(c.codec, v.b)
+	if err != nil || len(out) >= len(v.b) {
+		return v
+	}
+	v.n = len(v.b)
+	v.b = out
+	v.codec = c.codec
+	return v
+}
+
+// 压缩器和解压器的内部状态有几百 KB，复用它们避免每次压缩和解压都重新分配
+var (
+	gzipWriters  = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+	flateWriters = sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	}}
+	gzipReaders  sync.Pool
+	flateReaders sync.Pool
+)
+
+func compressBytes(codec Compression, b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch codec {
+	case CompressionGzip:
+		w := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(w)
+		w.Reset(&buf)
+		return finishCompress(w, &buf, b)
+	case CompressionFlate:
+		w := flateWriters.Get().(*flate.Writer)
+		defer flateWriters.Put(w)
+		w.Reset(&buf)
+		return finishCompress(w, &buf, b)
+	default:
+		return nil, fmt.Errorf("unknown compression %v", codec)
+	}
+}
+
+func finishCompress(w io.WriteCloser, buf *bytes.Buffer, b []byte) ([]byte, error) {
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressBytes 解压 b，rawSize 是已知的解压后长度，为 0 表示未知；长度与已知的不一致时返回错误
+func decompressBytes(codec Compression, b []byte, rawSize int) ([]byte, error) {
+	var r io.Reader
+	switch codec {
+	case CompressionNone:
+		return b, nil
+	case CompressionGzip:
+		gr, _ := gzipReaders.Get().(*gzip.Reader)
+		if gr == nil {
+			gr = new(gzip.Reader)
+		}
+		defer gzipReaders.Put(gr)
+		if err := gr.Reset(bytes.NewReader(b)); err != nil {
+			return nil, err
+		}
+		r = gr
+	case CompressionFlate:
+		fr, _ := flateReaders.Get().(io.ReadCloser)
+		if fr == nil {
+			fr = flate.NewReader(bytes.NewReader(b))
+		} else if err := fr.(flate.Resetter).Reset(bytes.NewReader(b), nil); err != nil {
+			return nil, err
+		}
+		defer flateReaders.Put(fr)
+		r = fr
+	default:
+		return nil, fmt.Errorf("unknown compression %v", codec)
+	}
+	var out bytes.Buffer
+	out.Grow(rawSize)
+	if _, err := out.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if rawSize > 0 && out.Len() != rawSize {
+		return nil, fmt.Errorf("%v value has %d bytes, want %d", codec, out.Len(), rawSize)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,605 @@
+package diskcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSegmentBytes 单个段文件的默认大小
+	DefaultSegmentBytes = 64 << 20
+	// DefaultCompactInterval 后台压缩的默认周期
+	DefaultCompactInterval = time.Minute
+	// DefaultCompactRatio 段中失效数据超过该比例时压缩
+	DefaultCompactRatio = 0.5
+
+	segmentSuffix = ".seg"
+
+	// 记录头：crc32 | flag | keyLen uint32 | valueLen uint32 | expire int64
+	headerSize = 4 + 1 + 4 + 4 + 8
+
+	flagPut    = 0
+	flagDelete = 1
+
+	// maxRecordBytes 是单条记录的上限，用于识别损坏的长度字段
+	maxRecordBytes = 1 << 30
+)
+
+var (
+	// ErrClosed 表示 Store 已经关闭
+	ErrClosed = errors.New("diskcache: closed")
+	// ErrCorrupt 表示记录校验失败
+	ErrCorrupt = errors.New("diskcache: corrupt record")
+)
+
+// Options 是磁盘缓存的配置
+type Options struct {
+	// Dir 是段文件所在目录，不存在时自动创建
+	Dir string
+	// MaxBytes 是所有段文件的总字节数上限，超出时删除最旧的段，0 表示不限制
+	MaxBytes int64
+	// SegmentBytes 是单个段文件的大小，默认 DefaultSegmentBytes，且不超过 MaxBytes 的 1/4
+	SegmentBytes int64
+	// CompactInterval 是后台压缩周期，默认 DefaultCompactInterval，小于 0 表示不在后台压缩
+	CompactInterval time.Duration
+	// CompactRatio 段中失效数据超过该比例时压缩，默认 DefaultCompactRatio
+	CompactRatio float64
+	// OnCompact 在每个段压缩完成后调用，reclaimed 是回收的字节数，可以为 nil
+	OnCompact func(reclaimed int64)
+}
+
+// Store 是只追加写入的磁盘键值存储：写入和删除都追加到当前段文件末尾，
+// 内存中的索引记录每个 key 最新记录的位置，旧段中的失效数据由压缩回收
+type Store struct {
+	opts Options
+
+	// compactMu 保证同一时间只有一个压缩在进行
+	compactMu sync.Mutex
+
+	mu       sync.RWMutex
+	index    map[string]location
+	segments map[uint32]*segment
+	order    []uint32 // 从旧到新
+	active   *segment
+	nbytes   int64
+	closed   bool
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type location struct {
+	seg    uint32
+	offset int64
+	size   int64
+	expire int64 // UnixNano，0 表示永不过期
+}
+
+type segment struct {
+	id   uint32
+	f    *os.File
+	size int64 // 文件字节数
+	live int64 // 仍被索引引用的记录字节数
+}
+
+type record struct {
+	flag   byte
+	key    string
+	value  []byte
+	expire int64
+}
+
+// Open 打开目录中已有的段文件并重建索引，末尾不完整的记录会被截断
+func Open(opts Options) (*Store, error) {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	if opts.MaxBytes > 0 && opts.SegmentBytes > opts.MaxBytes/4 {
+		opts.SegmentBytes = max(opts.MaxBytes/4, 1)
+	}
+	if opts.CompactInterval == 0 {
+		opts.CompactInterval = DefaultCompactInterval
+	}
+	if opts.CompactRatio <= 0 || opts.CompactRatio >= 1 {
+		opts.CompactRatio = DefaultCompactRatio
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		opts:     opts,
+		index:    make(map[string]location),
+		segments: make(map[uint32]*segment),
+		stopCh:   make(chan struct{}),
+	}
+	ids, err := s.segmentIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := s.openSegment(id); err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+	}
+	// 每次打开都从新的段开始写入
+	var next uint32 = 1
+	if len(ids) > 0 {
+		next = ids[len(ids)-1] + 1
+	}
+	if err := s.newSegment(next); err != nil {
+		s.closeFiles()
+		return nil, err
+	}
+	s.enforceBudgetLocked()
+
+	if opts.CompactInterval > 0 {
+		s.wg.Add(1)
+		go s.periodicCompact()
+	}
+	return s, nil
+}
+
+// Put 写入 key 的值，expire 为零值表示永不过期
+func (s *Store) Put(key string, value []byte, expire time.Time) error {
+	var exp int64
+	if !expire.IsZero() {
+		exp = expire.UnixNano()
+	}
+	rec := encodeRecord(record{flag: flagPut, key: key, value: value, expire: exp})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	loc, err := s.appendLocked(rec)
+	if err != nil {
+		return err
+	}
+	loc.expire = exp
+	s.unindexLocked(key)
+	s.index[key] = loc
+	s.segments[loc.seg].live += loc.size
+	s.enforceBudgetLocked()
+	return nil
+}
+
+// Get 读取 key 的值和过期时间，不存在、已过期或读取失败时返回 false
+func (s *Store) Get(key string) ([]byte, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, time.Time{}, false
+	}
+	loc, ok := s.index[key]
+	if !ok || (loc.expire != 0 && time.Now().UnixNano() > loc.expire) {
+		return nil, time.Time{}, false
+	}
+	buf := make([]byte, loc.size)
+	if _, err := s.segments[loc.seg].f.ReadAt(buf, loc.offset); err != nil {
+		return nil, time.Time{}, false
+	}
+	rec, err := decodeRecord(buf)
+	if err != nil || rec.key != key {
+		return nil, time.Time{}, false
+	}
+	var expire time.Time
+	if rec.expire != 0 {
+		expire = time.Unix(0, rec.expire)
+	}
+	return rec.value, expire, true
+}
+
+// Delete 删除 key，key 不存在时不写入任何数据
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, ok := s.index[key]; !ok {
+		return nil
+	}
+	// 追加删除标记，保证重新打开时不会读到旧值
+	if _, err := s.appendLocked(encodeRecord(record{flag: flagDelete, key: key})); err != nil {
+		return err
+	}
+	s.unindexLocked(key)
+	s.enforceBudgetLocked()
+	return nil
+}
+
+// Contains 判断 key 是否在索引中（可能已过期），只持有读锁，可以在写入或删除前用来跳过不必要的操作
+func (s *Store) Contains(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.index[key]
+	return ok
+}
+
+// Len 返回索引中的 key 数量，包括已过期但尚未回收的 key
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.index)
+}
+
+// Size 返回所有段文件的总字节数
+func (s *Store) Size() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nbytes
+}
+
+// Compact 压缩失效数据超过 CompactRatio 的旧段：把仍然有效的记录重新写入当前段，然后删除旧段
+// 旧段的读取和解码不持有锁，只在确认记录仍然有效、写入当前段并更新索引时持有写锁
+func (s *Store) Compact() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return ErrClosed
+	}
+	var candidates []*segment
+	for _, id := range s.order {
+		seg := s.segments[id]
+		if seg == s.active || seg.size == 0 {
+			continue
+		}
+		if float64(seg.size-seg.live)/float64(seg.size) >= s.opts.CompactRatio {
+			candidates = append(candidates, seg)
+		}
+	}
+	s.mu.RUnlock()
+
+	var err error
+	for _, seg := range candidates {
+		var n int64
+		if n, err = s.compactSegment(seg); err != nil {
+			break
+		}
+		if s.opts.OnCompact != nil && n > 0 {
+			s.opts.OnCompact(n)
+		}
+	}
+	return err
+}
+
+// Close 停止后台压缩并关闭所有段文件，可以重复调用
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.closeFiles()
+}
+
+func (s *Store) periodicCompact() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.CompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Compact()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// compactedRecord 是压缩时从旧段读出的记录
+type compactedRecord struct {
+	key    string
+	flag   byte
+	offset int64
+	buf    []byte // 重新编码后的记录
+}
+
+// compactSegment 把段中仍被索引引用且未过期的记录重新写入当前段，返回回收的字节数
+// 不是当前段的段不会再被写入，可以不持锁读取；读取期间段被整段删除时什么也不做
+func (s *Store) compactSegment(seg *segment) (int64, error) {
+	var recs []compactedRecord
+	r := bufio.NewReader(io.NewSectionReader(seg.f, 0, seg.size))
+	for off := int64(0); off < seg.size; {
+		rec, n, err := readRecord(r)
+		if err != nil {
+			s.mu.RLock()
+			removed := s.segments[seg.id] != seg
+			s.mu.RUnlock()
+			if removed {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("compact segment %d: %w", seg.id, err)
+		}
+		recs = append(recs, compactedRecord{key: rec.key, flag: rec.flag, offset: off, buf: encodeRecord(rec)})
+		off += n
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrClosed
+	}
+	if s.segments[seg.id] != seg {
+		return 0, nil
+	}
+	olderExists := s.order[0] != seg.id
+	now := time.Now().UnixNano()
+	var keep []compactedRecord
+	for _, rec := range recs {
+		loc, indexed := s.index[rec.key]
+		switch rec.flag {
+		case flagPut:
+			if !indexed || loc.seg != seg.id || loc.offset != rec.offset {
+				break
+			}
+			if loc.expire != 0 && now > loc.expire {
+				delete(s.index, rec.key)
+				break
+			}
+			keep = append(keep, rec)
+		case flagDelete:
+			// 更旧的段里可能还有这个 key 的旧值，删除标记需要保留；
+			// key 已经重新写入时不能保留，否则重新打开时删除标记会排在新值之后
+			if olderExists && !indexed {
+				keep = append(keep, rec)
+			}
+		}
+	}
+	bufs := make([][]byte, len(keep))
+	for i, rec := range keep {
+		bufs[i] = rec.buf
+	}
+	locs, err := s.appendManyLocked(bufs)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	for i, rec := range keep {
+		loc := locs[i]
+		written += loc.size
+		if rec.flag == flagPut {
+			loc.expire = s.index[rec.key].expire
+			s.index[rec.key] = loc
+			s.segments[loc.seg].live += loc.size
+		}
+	}
+	size := seg.size
+	s.removeSegmentLocked(seg)
+	return size - written, nil
+}
+
+// appendManyLocked 把多条记录按当前段的剩余空间合并成尽量少的写入，返回每条记录的位置
+func (s *Store) appendManyLocked(recs [][]byte) ([]location, error) {
+	locs := make([]location, 0, len(recs))
+	for len(recs) > 0 {
+		n, size := 1, int64(len(recs[0]))
+		for n < len(recs) && s.active.size+size+int64(len(recs[n])) <= s.opts.SegmentBytes {
+			size += int64(len(recs[n]))
+			n++
+		}
+		loc, err := s.appendLocked(bytes.Join(recs[:n], nil))
+		if err != nil {
+			return nil, err
+		}
+		off := loc.offset
+		for _, rec := range recs[:n] {
+			locs = append(locs, location{seg: loc.seg, offset: off, size: int64(len(rec))})
+			off += int64(len(rec))
+		}
+		recs = recs[n:]
+	}
+	return locs, nil
+}
+
+// appendLocked 把编码后的记录追加到当前段，当前段写满时先切换到新段
+func (s *Store) appendLocked(rec []byte) (location, error) {
+	if s.active.size > 0 && s.active.size+int64(len(rec)) > s.opts.SegmentBytes {
+		if err := s.newSegment(s.active.id + 1); err != nil {
+			return location{}, err
+		}
+	}
+	seg := s.active
+	if _, err := seg.f.WriteAt(rec, seg.size); err != nil {
+		return location{}, err
+	}
+	loc := location{seg: seg.id, offset: seg.size, size: int64(len(rec))}
+	seg.size += loc.size
+	s.nbytes += loc.size
+	return loc, nil
+}
+
+// unindexLocked 从索引中移除 key 并更新其所在段的有效字节数
+func (s *Store) unindexLocked(key string) {
+	if old, ok := s.index[key]; ok {
+		if seg, ok := s.segments[old.seg]; ok {
+			seg.live -= old.size
+		}
+		delete(s.index, key)
+	}
+}
+
+// enforceBudgetLocked 超出 MaxBytes 时从最旧的段开始整段删除
+func (s *Store) enforceBudgetLocked() {
+	for s.opts.MaxBytes > 0 && s.nbytes > s.opts.MaxBytes && len(s.order) > 1 {
+		oldest := s.segments[s.order[0]]
+		for key, loc := range s.index {
+			if loc.seg == oldest.id {
+				delete(s.index, key)
+			}
+		}
+		s.removeSegmentLocked(oldest)
+	}
+}
+
+func (s *Store) removeSegmentLocked(seg *segment) {
+	seg.f.Close()
+	os.Remove(seg.f.Name())
+	s.nbytes -= seg.size
+	delete(s.segments, seg.id)
+	for i, id := range s.order {
+		if id == seg.id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (s *Store) segmentPath(id uint32) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%08d%s", id, segmentSuffix))
+}
+
+// segmentIDs 按从旧到新的顺序列出目录中的段文件
+func (s *Store) segmentIDs() ([]uint32, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint32
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *Store) newSegment(id uint32) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	seg := &segment{id: id, f: f}
+	s.segments[id] = seg
+	s.order = append(s.order, id)
+	s.active = seg
+	return nil
+}
+
+// openSegment 扫描已有段文件并更新索引，遇到不完整或损坏的记录时从该处截断
+func (s *Store) openSegment(id uint32) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	seg := &segment{id: id, f: f}
+	s.segments[id] = seg
+	s.order = append(s.order, id)
+
+	r := bufio.NewReader(f)
+	for {
+		rec, n, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if err := f.Truncate(seg.size); err != nil {
+				return err
+			}
+			break
+		}
+		if rec.flag == flagPut {
+			s.unindexLocked(rec.key)
+			s.index[rec.key] = location{seg: id, offset: seg.size, size: n, expire: rec.expire}
+			seg.live += n
+		} else {
+			s.unindexLocked(rec.key)
+		}
+		seg.size += n
+	}
+	s.nbytes += seg.size
+	return nil
+}
+
+func (s *Store) closeFiles() error {
+	var errs []error
+	for _, seg := range s.segments {
+		if err := seg.f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func encodeRecord(rec record) []byte {
+	buf := make([]byte, headerSize+len(rec.key)+len(rec.value))
+	buf[4] = rec.flag
+	binary.BigEndian.PutUint32(buf[5:], uint32(len(rec.key)))
+	binary.BigEndian.PutUint32(buf[9:], uint32(len(rec.value)))
+	binary.BigEndian.PutUint64(buf[13:], uint64(rec.expire))
+	copy(buf[headerSize:], rec.key)
+	copy(buf[headerSize+len(rec.key):], rec.value)
+	binary.BigEndian.PutUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+func decodeRecord(buf []byte) (record, error) {
+	if len(buf) < headerSize || crc32.ChecksumIEEE(buf[4:]) != binary.BigEndian.Uint32(buf) {
+		return record{}, ErrCorrupt
+	}
+	keyLen := int(binary.BigEndian.Uint32(buf[5:]))
+	valueLen := int(binary.BigEndian.Uint32(buf[9:]))
+	if headerSize+keyLen+valueLen != len(buf) {
+		return record{}, ErrCorrupt
+	}
+	return record{
+		flag:   buf[4],
+		key:    string(buf[headerSize : headerSize+keyLen]),
+		value:  buf[headerSize+keyLen:],
+		expire: int64(binary.BigEndian.Uint64(buf[13:])),
+	}, nil
+}
+
+// readRecord 顺序读取一条记录，返回记录和它占用的字节数；文件正好结束时返回 io.EOF
+func readRecord(r io.Reader) (record, int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return record{}, 0, err
+	}
+	keyLen := binary.BigEndian.Uint32(header[5:])
+	valueLen := binary.BigEndian.Uint32(header[9:])
+	if uint64(keyLen)+uint64(valueLen) > maxRecordBytes {
+		return record{}, 0, ErrCorrupt
+	}
+	buf := make([]byte, headerSize+int(keyLen)+int(valueLen))
+	copy(buf, header)
+	if _, err := io.ReadFull(r, buf[headerSize:]); err != nil {
+		return record{}, 0, io.ErrUnexpectedEOF
+	}
+	rec, err := decodeRecord(buf)
+	if err != nil {
+		return record{}, 0, err
+	}
+	return rec, int64(len(buf)), nil
+}
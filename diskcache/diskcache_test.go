@@ -0,0 +1,167 @@
+package diskcache
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func open(t *testing.T, opts Options) *Store {
+	t.Helper()
+	if opts.CompactInterval == 0 {
+		opts.CompactInterval = -1
+	}
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPutGetDelete(t *testing.T) {
+	s := open(t, Options{Dir: t.TempDir()})
+	defer s.Close()
+
+	if err := s.Put("k1", []byte("v1"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	expire := time.Now().Add(time.Hour)
+	s.Put("k2", []byte("v2"), expire)
+	if v, exp, ok := s.Get("k1"); !ok || string(v) != "v1" || !exp.IsZero() {
+		t.Fatalf("Get(k1) = %q, %v, %v", v, exp, ok)
+	}
+	if v, exp, ok := s.Get("k2"); !ok || string(v) != "v2" || !exp.Equal(time.Unix(0, expire.UnixNano())) {
+		t.Fatalf("Get(k2) = %q, %v, %v", v, exp, ok)
+	}
+	s.Put("k1", []byte("v1-new"), time.Time{})
+	if v, _, _ := s.Get("k1"); string(v) != "v1-new" {
+		t.Fatalf("Get(k1) = %q after overwrite", v)
+	}
+	s.Delete("k1")
+	if _, _, ok := s.Get("k1"); ok {
+		t.Fatal("k1 should be deleted")
+	}
+	s.Put("gone", []byte("x"), time.Now().Add(-time.Second))
+	if _, _, ok := s.Get("gone"); ok {
+		t.Fatal("expired key should miss")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, Options{Dir: dir})
+	s.Put("k1", []byte("v1"), time.Time{})
+	s.Put("k2", []byte("v2"), time.Time{})
+	s.Delete("k1")
+	s.Close()
+
+	// 模拟写到一半崩溃：在最后一个段末尾追加半条记录
+	ids, _ := s.segmentIDs()
+	f, err := os.OpenFile(s.segmentPath(ids[len(ids)-1]), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(encodeRecord(record{key: "k3", value: []byte("v3")})[:headerSize+1])
+	f.Close()
+
+	s = open(t, Options{Dir: dir})
+	defer s.Close()
+	if _, _, ok := s.Get("k1"); ok {
+		t.Fatal("deleted key resurrected after reopen")
+	}
+	if v, _, ok := s.Get("k2"); !ok || string(v) != "v2" {
+		t.Fatalf("Get(k2) = %q, %v after reopen", v, ok)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", s.Len())
+	}
+}
+
+func TestBudget(t *testing.T) {
+	s := open(t, Options{Dir: t.TempDir(), MaxBytes: 4 << 10})
+	defer s.Close()
+	value := make([]byte, 100)
+	for i := 0; i < 200; i++ {
+		s.Put(fmt.Sprintf("key-%03d", i), value, time.Time{})
+		if s.Size() > 4<<10 {
+			t.Fatalf("size %d exceeds budget", s.Size())
+		}
+	}
+	if _, _, ok := s.Get("key-000"); ok {
+		t.Fatal("oldest key should be dropped")
+	}
+	if _, _, ok := s.Get("key-199"); !ok {
+		t.Fatal("newest key should be kept")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	var reclaimed int64
+	s := open(t, Options{Dir: dir, SegmentBytes: 1 << 10, OnCompact: func(n int64) { reclaimed += n }})
+	value := make([]byte, 50)
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 20; i++ {
+			s.Put(fmt.Sprintf("key-%02d", i), value, time.Time{})
+		}
+	}
+	s.Delete("key-00")
+	before := s.Size()
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Size() >= before || reclaimed == 0 {
+		t.Fatalf("compaction reclaimed nothing: %d -> %d", before, s.Size())
+	}
+	check := func() {
+		for i := 1; i < 20; i++ {
+			if _, _, ok := s.Get(fmt.Sprintf("key-%02d", i)); !ok {
+				t.Fatalf("key-%02d lost", i)
+			}
+		}
+		if _, _, ok := s.Get("key-00"); ok {
+			t.Fatal("deleted key resurrected")
+		}
+	}
+	check()
+	s.Close()
+	s = open(t, Options{Dir: dir, SegmentBytes: 1 << 10})
+	defer s.Close()
+	check()
+}
+
+// 压缩时删除标记不能排到同一个 key 更新的值之后，否则重新打开后新值丢失
+func TestCompactKeepsRewrittenKey(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, Options{Dir: dir, SegmentBytes: 1 << 10})
+	value := make([]byte, 50)
+	// 段 1：a 的旧值和一直有效的 key，不满足压缩条件
+	s.Put("a", value, time.Time{})
+	for i := 0; i < 12; i++ {
+		s.Put(fmt.Sprintf("live-%02d", i), value, time.Time{})
+	}
+	// 段 2：a 的删除标记和之后会被覆盖的 key
+	s.Delete("a")
+	for i := 0; i < 13; i++ {
+		s.Put(fmt.Sprintf("f-%02d", i), value, time.Time{})
+	}
+	// 之后的段：a 重新写入，段 2 全部失效
+	s.Put("a", value, time.Time{})
+	for i := 0; i < 13; i++ {
+		s.Put(fmt.Sprintf("f-%02d", i), value, time.Time{})
+	}
+	if !s.Contains("a") {
+		t.Fatal("a should be indexed")
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = open(t, Options{Dir: dir, SegmentBytes: 1 << 10})
+	defer s.Close()
+	if _, _, ok := s.Get("a"); !ok {
+		t.Fatal("rewritten key lost after compaction and reopen")
+	}
+}
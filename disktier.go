@@ -0,0 +1,128 @@
+package distcache
+
+import (
+	"encoding/binary"
+	"log"
+	"time"
+
+	"github.com/simplely77/distcache/diskcache"
+)
+
+// openDiskTier 为缓存打开磁盘层，打开失败时记录日志并不启用磁盘层
+func (c *cache) openDiskTier(opts diskcache.Options) {
+	onCompact := opts.OnCompact
+	opts.OnCompact = func(reclaimed int64) {
+		if IsMetricsEnabled() && c.groupName != "" {
+			GetMetrics().RecordDiskCompaction(c.groupName)
+		}
+		if onCompact != nil {
+			onCompact(reclaimed)
+		}
+	}
+	store, err := diskcache.Open(opts)
+	if err != nil {
+		log.Printf("[DistCache] open disk tier %s for group %s failed: %v", opts.Dir, c.groupName, err)
+		return
+	}
+	c.disk = store
+}
+
+// spillToDisk 把因容量不足被淘汰的条目写入磁盘层，已过期的条目直接丢弃，需持有条目所在分片的锁
+// 写入的数据以一个字节的压缩方式开头，值被压缩时接着是 uvarint 编码的解压后长度，最后是（可能已压缩的）值
+func (c *cache) spillToDisk(key string, value ByteView) {
+	if c.disk == nil || value.expired(time.Now()) {
+		return
+	}
+	data := make([]byte, 0, 1+binary.MaxVarintLen64+len(value.b))
+	data = append(data, byte(value.codec))
+	if value.codec != CompressionNone {
+		data = binary.AppendUvarint(data, uint64(value.n))
+	}
+	data = append(data, value.b...)
+	if err := c.disk.Put(key, data, value.expire); err != nil {
+		if IsLoggingEnabled() {
+			log.Printf("[DistCache] spill %s to disk failed: %v", key, err)
+		}
+		return
+	}
+	if IsMetricsEnabled() && c.groupName != "" {
+		GetMetrics().RecordDiskSpill(c.groupName)
+		GetMetrics().SetDiskSize(c.groupName, c.disk.Size())
+	}
+}
+
+// getFromDisk 从磁盘层读取条目，命中后写回内存，磁盘上的值保留到条目被替换或删除
+// 读取和写回都持有分片锁，避免与同一 key 的写入、删除和溢出交错；写回不经过准入，
+// 条目淘汰时磁盘上已经有同样的值，不会再写一次
+func (c *cache) getFromDisk(key string) (ByteView, bool) {
+	if c.disk == nil {
+		return ByteView{}, false
+	}
+	shard := c.getShard(key)
+	shard.mu.Lock()
+	if shard.policy == nil {
+		shard.mu.Unlock()
+		return ByteView{}, false
+	}
+	if _, ok := shard.policy.Peek(key); ok {
+		// 读取磁盘之前已有新值写入内存
+		shard.mu.Unlock()
+		return c.getMemory(key)
+	}
+	var value ByteView
+	b, expire, ok := c.disk.Get(key)
+	if ok {
+		value, ok = decodeDiskValue(b)
+	}
+	if !ok {
+		shard.mu.Unlock()
+		return ByteView{}, false
+	}
+	value.expire = expire
+	now := time.Now()
+	before := shard.policy.NBytes()
+	shard.policy.Add(key, &cacheEntry{value: value, inserted: now, lastAccess: now, onDisk: true})
+	c.nbytes.Add(shard.policy.NBytes() - before)
+	if c.admission != nil {
+		c.admission.record(key)
+	}
+	c.hotDetector.RecordKey(key, value)
+	evicted := c.drain(shard)
+	shard.mu.Unlock()
+
+	c.notifyEvicted(evicted)
+	c.evict()
+	c.updateCacheSizeMetrics()
+	if IsMetricsEnabled() {
+		GetMetrics().RecordHit("disk")
+	}
+	c.recordHit()
+	return value, true
+}
+
+// decodeDiskValue 解析 spillToDisk 写入的数据，格式不正确时返回 false
+func decodeDiskValue(b []byte) (ByteView, bool) {
+	if len(b) == 0 {
+		return ByteView{}, false
+	}
+	value := ByteView{codec: Compression(b[0])}
+	b = b[1:]
+	if value.codec != CompressionNone {
+		n, k := binary.Uvarint(b)
+		if k <= 0 || n == 0 {
+			return ByteView{}, false
+		}
+		value.n = int(n)
+		b = b[k:]
+	}
+	value.b = b
+	return value, true
+}
+
+// deleteFromDisk 删除磁盘层中的旧值，key 不在磁盘层时不做任何写入
+func (c *cache) deleteFromDisk(key string) {
+	if c.disk == nil || !c.disk.Contains(key) {
+		return
+	}
+	c.disk.Delete(key)
+}
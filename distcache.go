@@ -1,11 +1,15 @@
 package distcache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/simplely77/distcache/bloomfilter"
 	"github.com/simplely77/distcache/singleflight"
 )
 
@@ -15,8 +19,17 @@ const (
 	DefaultHotKeyThreshold = 10
 	// 默认衰减周期：每5分钟进行一次频率衰减
 	DefaultDecayInterval = 5 * time.Minute
+	// 默认负缓存过期时间：不存在的 key 在这段时间内不会再次请求数据源
+	DefaultNegativeTTL = 10 * time.Second
 )
 
+// ErrNotFound 表示 key 在数据源中不存在
+// Getter 返回的错误满足 errors.Is(err, ErrNotFound) 时，Group 会将其作为负缓存条目缓存
+var ErrNotFound = errors.New("key not found")
+
+// ErrGroupClosed 表示 Group 已经被关闭
+var ErrGroupClosed = errors.New("group closed")
+
 // Group 是缓存的核心数据结构，负责与用户交互
 type Group struct {
 	name string
@@ -28,6 +41,34 @@ type Group struct {
 	peers PeerPicker
 	// 使每个 key 并发状况下只被请求一次
 	loader *singleflight.Group
+	// 默认过期时间，0 表示永不过期
+	ttl time.Duration
+	// 负缓存过期时间，0 表示不缓存不存在的 key
+	negativeTTL time.Duration
+	// 提前刷新窗口，条目剩余有效期小于该值时返回旧值并在后台刷新，0 表示不刷新
+	refreshAhead time.Duration
+	// 正在后台刷新的 key，保证每个 key 同时只有一个刷新协程
+	refreshing sync.Map
+	// 可选的存在性过滤器，声明包含全部 key 后，未命中的 key 直接视为不存在，不再调用加载器
+	bloom         *bloomfilter.BloomFilter
+	bloomComplete atomic.Bool
+	// 快照文件路径，创建时从中加载，GRPCPool.Stop 时写入，为空表示不自动快照
+	snapshotPath string
+	// 是否把本节点作为主节点时的热点广播给其他所有节点
+	hotReplication bool
+	// 推送给其他节点的热点的租约
+	hotLease time.Duration
+	// 热点推送的版本和每个 key 等待推送的最新状态，值为 nil 表示推送协程正在运行但没有新的状态
+	hotMu      sync.Mutex
+	hotVersion uint64
+	hotQueue   map[string]*hotPush
+	// 已推送给其他节点的热点及其租约到期时间，只有它们需要撤销
+	hotPushed map[string]time.Time
+	// 关闭时停止热点续约
+	hotStop chan struct{}
+	// 关闭标记，关闭后所有读写都返回 ErrGroupClosed
+	closed    atomic.Bool
+	closeOnce sync.Once
 }
 
 // Getter 用于获取源数据，可以是本地文件、数据库，或远程 API
@@ -42,6 +83,46 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
+// ContextGetter 是支持 context 的 Getter，调用方 ctx 中的值会传递给数据源
+// 加载由同一 key 的所有调用方共享，不随调用方取消，截止时间为默认的 RPC 超时
+// 如果 Group 的 getter 同时实现了 ContextGetter，加载数据时优先使用 GetContext
+type ContextGetter interface {
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+// ContextGetterFunc 函数式实现 ContextGetter，同时实现了 Getter，可以直接传给 NewGroup
+type ContextGetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+func (f ContextGetterFunc) Get(key string) ([]byte, error) {
+	return f(context.Background(), key)
+}
+
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// TTLGetter 在返回数据的同时返回该 key 的过期时间，优先级高于 Group 的默认 TTL
+// ttl <= 0 时使用 Group 的默认 TTL
+type TTLGetter interface {
+	GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error)
+}
+
+// TTLGetterFunc 函数式实现 TTLGetter，同时实现了 Getter 和 ContextGetter
+type TTLGetterFunc func(ctx context.Context, key string) ([]byte, time.Duration, error)
+
+func (f TTLGetterFunc) Get(key string) ([]byte, error) {
+	return f.GetContext(context.Background(), key)
+}
+
+func (f TTLGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	b, _, err := f(ctx, key)
+	return b, err
+}
+
+func (f TTLGetterFunc) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	return f(ctx, key)
+}
+
 // 全局变量，存储所有创建的 Group，这是所有的本地的group的集合，不同节点的group是不同的
 var (
 	mu     sync.RWMutex
@@ -49,40 +130,149 @@ var (
 )
 
 func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
-	if getter == nil {
-		panic("nil getter")
-	}
-	mu.Lock()
-	defer mu.Unlock()
-	g := &Group{
-		name:      name,
-		getter:    getter,
-		mainCache: newCache(cacheBytes, DefaultHotKeyThreshold, DefaultDecayInterval),
-		loader:    &singleflight.Group{},
-	}
-	g.mainCache.groupName = name
-	groups[name] = g
-	return g
+	return NewGroupWithOptions(name, getter, WithCacheBytes(cacheBytes))
 }
 
 // NewGroupWithHotKeyConfig 创建一个带有自定义热点缓存配置的Group
 func NewGroupWithHotKeyConfig(name string, cacheBytes int64, getter Getter, hotThreshold uint64, decayInterval time.Duration) *Group {
+	return NewGroupWithOptions(name, getter,
+		WithCacheBytes(cacheBytes),
+		WithHotKeyThreshold(hotThreshold),
+		WithDecayInterval(decayInterval),
+	)
+}
+
+// NewGroupWithOptions 使用可选配置创建 Group，未设置的配置使用默认值
+func NewGroupWithOptions(name string, getter Getter, opts ...Option) *Group {
 	if getter == nil {
 		panic("nil getter")
 	}
-	mu.Lock()
-	defer mu.Unlock()
+	o := defaultGroupOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.budget != nil {
+		// 分片的上限设为整个预算，实际容量由预算分配
+		o.cacheBytes = o.budget.totalBytes
+	}
 	g := &Group{
-		name:      name,
-		getter:    getter,
-		mainCache: newCache(cacheBytes, hotThreshold, decayInterval),
-		loader:    &singleflight.Group{},
+		name:   name,
+		getter: getter,
+		mainCache: newCacheWithOptions(cacheOptions{
+			cacheBytes:    o.cacheBytes,
+			hotThreshold:  o.hotThreshold,
+			decayInterval: o.decayInterval,
+			shardCount:    o.shardCount,
+			hash:          o.hash,
+			policy:        o.policy,
+			admission:     o.admission,
+			hotMaxKeys:    o.hotMaxKeys,
+			hotMaxBytes:   o.hotMaxBytes,
+			compressor:    o.compressor,
+		}),
+		loader:       &singleflight.Group{},
+		ttl:          o.ttl,
+		negativeTTL:  o.negativeTTL,
+		refreshAhead: o.refreshAhead,
+		peers:        o.peers,
+		snapshotPath: o.snapshotPath,
 	}
 	g.mainCache.groupName = name
+	if o.hotReplication {
+		g.hotReplication = true
+		g.hotLease = o.hotLease
+		g.hotQueue = make(map[string]*hotPush)
+		g.hotPushed = make(map[string]time.Time)
+		g.hotStop = make(chan struct{})
+		g.mainCache.hotDetector.setOnChange(g.onHotKeyChange)
+		go g.renewHotKeys()
+	}
+	if o.disk != nil {
+		g.mainCache.openDiskTier(*o.disk)
+	}
+	if o.budget != nil {
+		o.budget.join(g.mainCache, o.budgetWeight)
+	}
+	if o.bloomExpectedKeys > 0 {
+		g.EnableBloomFilter(o.bloomExpectedKeys, o.bloomFPRate, o.bloomKeys...)
+		g.SetBloomFilterComplete(o.bloomComplete)
+	}
+	if o.snapshotPath != "" {
+		n, err := g.loadSnapshotFile(o.snapshotPath)
+		if err != nil {
+			log.Printf("[DistCache] load snapshot %s for group %s failed: %v", o.snapshotPath, name, err)
+		} else if IsLoggingEnabled() {
+			log.Printf("[DistCache] loaded %d entries from snapshot %s", n, o.snapshotPath)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
 	groups[name] = g
 	return g
 }
 
+// SetDefaultTTL 设置 Group 的默认过期时间，0 表示永不过期，应在使用 Group 之前调用
+func (g *Group) SetDefaultTTL(ttl time.Duration) {
+	g.ttl = ttl
+}
+
+// SetNegativeTTL 设置负缓存过期时间，0 表示不缓存不存在的 key，应在使用 Group 之前调用
+func (g *Group) SetNegativeTTL(ttl time.Duration) {
+	g.negativeTTL = ttl
+}
+
+// SetRefreshAhead 设置提前刷新窗口：命中的条目剩余有效期小于 window 时，
+// 直接返回旧值并在后台重新加载，0 表示不刷新，应在使用 Group 之前调用
+func (g *Group) SetRefreshAhead(window time.Duration) {
+	g.refreshAhead = window
+}
+
+// EnableBloomFilter 启用布隆过滤器防止缓存穿透，应在使用 Group 之前调用
+// keys 为已存在的 key，之后成功加载（包括从远程节点加载）或写入的 key 会被自动加入过滤器；
+// 过滤器只有在 SetBloomFilterComplete 声明已包含全部 key 之后才会拒绝未命中的 key，在此之前未命中的 key 照常加载
+func (g *Group) EnableBloomFilter(expectedKeys uint, falsePositiveRate float64, keys ...string) {
+	g.bloom = bloomfilter.NewBloomFilterWithEstimates(expectedKeys, falsePositiveRate)
+	g.AddBloomKeys(keys...)
+}
+
+// AddBloomKeys 将 key 加入布隆过滤器，例如数据源新增数据后调用，未启用过滤器时什么也不做
+func (g *Group) AddBloomKeys(keys ...string) {
+	if g.bloom == nil {
+		return
+	}
+	for _, key := range keys {
+		g.bloom.Add(key)
+	}
+}
+
+// SetBloomFilterComplete 声明布隆过滤器已包含数据源中全部的 key，之后过滤器中不存在的 key 直接返回 ErrNotFound
+// 各节点的过滤器相互独立，数据源新增 key 时需要在所有节点调用 AddBloomKeys
+func (g *Group) SetBloomFilterComplete(complete bool) {
+	g.bloomComplete.Store(complete)
+}
+
+// OnEvict 注册淘汰回调，条目因容量不足、过期、删除或被覆盖离开本地缓存时调用，可以注册多个
+// 回调在触发淘汰的 goroutine 中同步执行，不持有分片锁，但应尽快返回
+func (g *Group) OnEvict(fn func(key string, value ByteView, reason EvictReason)) {
+	g.mainCache.addEvictHook(fn)
+}
+
+// mayExist 查询布隆过滤器，未启用过滤器或过滤器没有包含全部 key 时总是返回 true
+func (g *Group) mayExist(key string) bool {
+	if g.bloom == nil || !g.bloomComplete.Load() {
+		return true
+	}
+	ok := g.bloom.Test(key)
+	if IsMetricsEnabled() {
+		if ok {
+			GetMetrics().RecordBloomFilter("hit")
+		} else {
+			GetMetrics().RecordBloomFilter("miss")
+		}
+	}
+	return ok
+}
+
 func GetGroup(name string) *Group {
 	mu.RLock()
 	g := groups[name]
@@ -90,9 +280,38 @@ func GetGroup(name string) *Group {
 	return g
 }
 
+// Close 关闭 Group：停止后台协程、释放缓存分片并从全局注册表中移除
+// 关闭后的 Get、GetMany 和 Set 都会返回 ErrGroupClosed，可以重复调用
+func (g *Group) Close() {
+	g.closeOnce.Do(func() {
+		g.closed.Store(true)
+		mu.Lock()
+		if groups[g.name] == g {
+			delete(groups, g.name)
+		}
+		mu.Unlock()
+		if g.hotStop != nil {
+			close(g.hotStop)
+		}
+		g.mainCache.close()
+	})
+}
+
+// DestroyGroup 关闭并移除指定名称的 Group，Group 不存在时什么也不做
+func DestroyGroup(name string) {
+	if g := GetGroup(name); g != nil {
+		g.Close()
+	}
+}
+
 // if key exists in mainCache, return it directly
 // otherwise, load it from the underlying getter
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext 与 Get 相同，但 ctx 结束时提前返回；共享的加载继续执行，不影响等待同一 key 的其他调用方
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
 	start := time.Now()
 
 	if key == "" {
@@ -103,10 +322,27 @@ func (g *Group) Get(key string) (ByteView, error) {
 		return ByteView{}, fmt.Errorf("key is required")
 	}
 
+	if g.closed.Load() {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("get", "error")
+			GetMetrics().RecordDuration("get", "error", time.Since(start).Seconds())
+		}
+		return ByteView{}, ErrGroupClosed
+	}
+
 	if v, ok := g.mainCache.get(key); ok {
 		if IsLoggingEnabled() {
 			log.Println("[DistCache] hit")
 		}
+		// 命中负缓存，说明 key 不存在
+		if v.negative {
+			if IsMetricsEnabled() {
+				GetMetrics().RecordRequest("get", "not_found")
+				GetMetrics().RecordDuration("get", "not_found", time.Since(start).Seconds())
+			}
+			return ByteView{}, ErrNotFound
+		}
+		g.maybeRefresh(key, v)
 		if IsMetricsEnabled() {
 			GetMetrics().RecordRequest("get", "success")
 			GetMetrics().RecordDuration("get", "success", time.Since(start).Seconds())
@@ -114,9 +350,21 @@ func (g *Group) Get(key string) (ByteView, error) {
 		return v, nil
 	}
 
-	value, err := g.load(key)
+	// 布隆过滤器中不存在的 key 一定不存在，不再访问远程节点和数据源
+	if !g.mayExist(key) {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("get", "not_found")
+			GetMetrics().RecordDuration("get", "not_found", time.Since(start).Seconds())
+		}
+		return ByteView{}, ErrNotFound
+	}
+
+	value, err := g.load(ctx, key)
 	if IsMetricsEnabled() {
-		if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			GetMetrics().RecordRequest("get", "not_found")
+			GetMetrics().RecordDuration("get", "not_found", time.Since(start).Seconds())
+		} else if err != nil {
 			GetMetrics().RecordRequest("get", "error")
 			GetMetrics().RecordDuration("get", "error", time.Since(start).Seconds())
 		} else {
@@ -127,10 +375,90 @@ func (g *Group) Get(key string) (ByteView, error) {
 	return value, err
 }
 
+// SetOptions 是 Set 的可选参数
+type SetOptions struct {
+	// 过期时间，0 表示使用 Group 的默认 TTL
+	TTL time.Duration
+}
+
+// Set 将应用已经拿到的新值写入缓存，opts 可以为 nil
+func (g *Group) Set(key string, value []byte, opts *SetOptions) error {
+	return g.SetContext(context.Background(), key, value, opts)
+}
+
+// SetContext 将值写入 key 所属的主节点，成功后再异步同步到副本节点
+// 主节点写入失败时返回错误，本节点就是主节点时直接写入本地缓存
+func (g *Group) SetContext(ctx context.Context, key string, value []byte, opts *SetOptions) error {
+	start := time.Now()
+	if key == "" {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("set", "error")
+			GetMetrics().RecordDuration("set", "error", time.Since(start).Seconds())
+		}
+		return fmt.Errorf("key is required")
+	}
+
+	ttl := g.ttl
+	if opts != nil && opts.TTL > 0 {
+		ttl = opts.TTL
+	}
+	// 克隆一份数据，避免调用方之后修改底层数组；压缩后的数据本身就是新分配的
+	view := g.mainCache.compressor.compress(ByteView{b: cloneBytes(value)})
+	if ttl > 0 {
+		view.expire = time.Now().Add(ttl)
+	}
+
+	var err error
+	if g.closed.Load() {
+		err = ErrGroupClosed
+	} else {
+		err = g.setToOwner(ctx, key, view)
+	}
+	if IsMetricsEnabled() {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		GetMetrics().RecordRequest("set", status)
+		GetMetrics().RecordDuration("set", status, time.Since(start).Seconds())
+	}
+	return err
+}
+
+// setToOwner 将值写入主节点，主节点不是本节点时由本节点负责同步副本
+func (g *Group) setToOwner(ctx context.Context, key string, value ByteView) error {
+	if g.peers == nil {
+		g.set(key, value, addExplicit)
+		return nil
+	}
+	owner, ok := g.peers.PickPeer(key)
+	if !ok {
+		g.set(key, value, addExplicit)
+		return nil
+	}
+	if err := owner.Set(ctx, g.name, key, value); err != nil {
+		return fmt.Errorf("set on owner peer failed: %w", err)
+	}
+	g.AddBloomKeys(key)
+	// 本地可能持有旧的副本或热点，直接删除
+	g.mainCache.delete(key)
+	for _, peer := range g.peers.ReplicaPeersForKey(key) {
+		if peer == owner {
+			continue
+		}
+		// 异步添加副本
+		go func(p PeerClient) {
+			p.Set(context.Background(), g.name, key, value)
+		}(peer)
+	}
+	return nil
+}
+
 // set 是内部方法，用于设置缓存并同步到副本节点
-// 只在从底层数据源加载数据时调用，不对外暴露
-func (g *Group) set(key string, value ByteView) {
-	g.mainCache.add(key, value)
+// 在从底层数据源加载数据或本节点作为主节点写入时调用，显式写入传入 addExplicit
+func (g *Group) set(key string, value ByteView, flags addFlags) {
+	g.mainCache.addWith(key, value, flags)
+	g.AddBloomKeys(key)
 
 	if g.peers == nil {
 		return
@@ -138,12 +466,16 @@ func (g *Group) set(key string, value ByteView) {
 	for _, peer := range g.peers.ReplicaPeersForKey(key) {
 		// 异步添加副本
 		go func(p PeerClient) {
-			p.Set(g.name, key, value.ByteSlice())
+			p.Set(context.Background(), g.name, key, value)
 		}(peer)
 	}
 }
 
 func (g *Group) Delete(key string) {
+	// 本节点上的热点可能已经降级，但其他节点上推送的值还在租约内，主节点撤销所有仍在租约内的推送
+	if g.hotReplication && g.peers != nil && g.isOwner(key) {
+		g.queueHotPush(key, ByteView{}, false)
+	}
 	g.mainCache.delete(key)
 
 	if g.peers == nil {
@@ -152,14 +484,24 @@ func (g *Group) Delete(key string) {
 	for _, peer := range g.peers.ReplicaPeersForKey(key) {
 		// 异步删除副本
 		go func(p PeerClient) {
-			p.Delete(g.name, key)
+			p.Delete(context.Background(), g.name, key)
 		}(peer)
 	}
 }
 
-// setCache 直接设置缓存，用于副本同步，不触发进一步的副本同步
+// setNegative 缓存不存在的 key，负缓存只保存在本地，不同步到副本
+func (g *Group) setNegative(key string) {
+	if g.negativeTTL <= 0 {
+		return
+	}
+	g.mainCache.add(key, ByteView{negative: true, expire: time.Now().Add(g.negativeTTL)})
+}
+
+// setCache 直接设置缓存，用于副本同步，不触发进一步的副本同步，不经过准入过滤
 func (g *Group) setCache(key string, value ByteView) {
-	g.mainCache.add(key, value)
+	value = g.mainCache.compressor.compress(value)
+	g.mainCache.addWith(key, value, addExplicit)
+	g.AddBloomKeys(key)
 }
 
 // RegisterPeers registers a PeerPicker for choosing remote peers
@@ -171,28 +513,45 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 }
 
 // load the key's value from the underlying getter
-func (g *Group) load(key string) (value ByteView, err error) {
-	view, err := g.loader.Do(key, func() (interface{}, error) {
+func (g *Group) load(ctx context.Context, key string) (value ByteView, err error) {
+	view, err := g.loader.DoContext(ctx, key, func() (interface{}, error) {
+		// 加载由等待同一 key 的所有调用方共享，不随第一个调用方取消，每个调用方只在自己的 ctx 结束时提前返回
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultRPCTimeout)
+		defer cancel()
 		if g.peers != nil {
 			if peer, ok := g.peers.PickPeer(key); ok {
-				if value, err := g.getFromPeer(peer, key); err == nil {
+				value, err := g.getFromPeer(ctx, peer, key)
+				if err == nil {
 					if IsMetricsEnabled() {
 						GetMetrics().RecordHit("remote")
 					}
+					g.AddBloomKeys(key)
 					return value, nil
 				}
+				// 主节点明确返回不存在，不再读取副节点和数据源
+				if errors.Is(err, ErrNotFound) {
+					g.setNegative(key)
+					return nil, err
+				}
 				// 主节点失败，读取副节点
 				for _, peer := range g.peers.ReplicaPeersForKey(key) {
-					if value, err := g.getFromPeer(peer, key); err == nil {
+					if ctx.Err() != nil {
+						break
+					}
+					if value, err := g.getFromPeer(ctx, peer, key); err == nil {
 						if IsMetricsEnabled() {
 							GetMetrics().RecordHit("remote")
 						}
+						g.AddBloomKeys(key)
 						return value, nil
 					}
 				}
 			}
 		}
-		return g.getLocally(key)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return g.getLocally(ctx, key)
 	})
 	if err == nil {
 		return view.(ByteView), nil
@@ -200,23 +559,75 @@ func (g *Group) load(key string) (value ByteView, err error) {
 	return
 }
 
-func (g *Group) getFromPeer(peer PeerClient, key string) (ByteView, error) {
-	// 通过 peer 获取数据
-	bytes, err := peer.Get(g.name, key)
-	if err != nil {
-		return ByteView{}, err
+// maybeRefresh 在条目即将过期时通过 singleflight 在后台重新加载
+// 只有本节点是主节点时才刷新，刷新结果经 set 写入分片并同步到副本
+func (g *Group) maybeRefresh(key string, value ByteView) {
+	if g.refreshAhead <= 0 || value.expire.IsZero() || time.Until(value.expire) > g.refreshAhead {
+		return
+	}
+	if g.peers != nil {
+		if _, ok := g.peers.PickPeer(key); ok {
+			return
+		}
 	}
-	return ByteView{b: bytes}, nil
+	if _, loading := g.refreshing.LoadOrStore(key, struct{}{}); loading {
+		return
+	}
+	go func() {
+		defer g.refreshing.Delete(key)
+		if g.closed.Load() {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultRPCTimeout)
+		defer cancel()
+		_, err := g.loader.DoContext(ctx, key, func() (interface{}, error) {
+			return g.getLocally(ctx, key)
+		})
+		if IsLoggingEnabled() && err != nil {
+			log.Printf("[DistCache] refresh %s failed: %v", key, err)
+		}
+		if IsMetricsEnabled() {
+			status := "success"
+			if err != nil {
+				status = "error"
+			}
+			GetMetrics().RecordRequest("refresh", status)
+		}
+	}()
+}
+
+func (g *Group) getFromPeer(ctx context.Context, peer PeerClient, key string) (ByteView, error) {
+	// 通过 peer 获取数据，压缩的值原样缓存，读取时才解压
+	return peer.Get(ctx, g.name, key)
 }
 
-func (g *Group) getLocally(key string) (ByteView, error) {
-	// 从本地数据源获取数据
-	bytes, err := g.getter.Get(key)
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	// 从本地数据源获取数据，支持 TTL 和 context 的 getter 优先
+	var bytes []byte
+	var ttl time.Duration
+	var err error
+	switch getter := g.getter.(type) {
+	case TTLGetter:
+		bytes, ttl, err = getter.GetWithTTL(ctx, key)
+	case ContextGetter:
+		bytes, err = getter.GetContext(ctx, key)
+	default:
+		bytes, err = g.getter.Get(key)
+	}
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			g.setNegative(key)
+		}
 		return ByteView{}, err
 	}
+	if ttl <= 0 {
+		ttl = g.ttl
+	}
 	// 克隆一份数据，避免外部数据源持有对底层数组的引用
-	value := ByteView{b: cloneBytes(bytes)}
-	g.set(key, value)
+	value := g.mainCache.compressor.compress(ByteView{b: cloneBytes(bytes)})
+	if ttl > 0 {
+		value.expire = time.Now().Add(ttl)
+	}
+	g.set(key, value, 0)
 	return value, nil
 }
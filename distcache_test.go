@@ -1,10 +1,25 @@
 package distcache
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/simplely77/distcache/diskcache"
 )
 
 // test Getter and GetterFunc
@@ -47,4 +62,1597 @@ func TestGet(t *testing.T){
 	if view,err:=group.Get("unknown");err==nil{
 		t.Fatalf("the value of unknow should be empty,but %s got",view)
 	}
-}
\ No newline at end of file
+}
+
+// test GetContext propagates deadline to ContextGetter
+func TestGetContext(t *testing.T) {
+	group := NewGroup("ctx-scores", 2<<10, ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, fmt.Errorf("deadline not propagated")
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(50 * time.Millisecond):
+				return []byte(key), nil
+			}
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if view, err := group.GetContext(ctx, "Tom"); err != nil || view.String() != "Tom" {
+		t.Fatalf("GetContext failed: %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := group.GetContext(ctx, "Jack"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// test a waiter gets the shared load even if the caller that started it gives up
+func TestGetContextSharedLoad(t *testing.T) {
+	started := make(chan struct{})
+	var loads atomic.Int32
+	group := NewGroup("ctx-shared", 2<<10, ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			loads.Add(1)
+			close(started)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(100 * time.Millisecond):
+				return []byte(key), nil
+			}
+		}))
+	defer group.Close()
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := group.GetContext(leaderCtx, "Tom")
+		leaderErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if view, err := group.GetContext(ctx, "Tom"); err != nil || view.String() != "Tom" {
+		t.Fatalf("follower got %q, %v", view.String(), err)
+	}
+	if err := <-leaderErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("leader expected deadline exceeded, got %v", err)
+	}
+	if loads.Load() != 1 {
+		t.Fatalf("getter called %d times, want 1", loads.Load())
+	}
+}
+
+// test default TTL and per-key TTL returned by TTLGetter
+func TestGetWithTTL(t *testing.T) {
+	loadCounts := make(map[string]int)
+	group := NewGroup("ttl-scores", 2<<10, TTLGetterFunc(
+		func(ctx context.Context, key string) ([]byte, time.Duration, error) {
+			loadCounts[key]++
+			if key == "short" {
+				return []byte(key), 20 * time.Millisecond, nil
+			}
+			return []byte(key), 0, nil
+		}))
+	group.SetDefaultTTL(time.Hour)
+
+	view, err := group.Get("short")
+	if err != nil || view.Expire().IsZero() {
+		t.Fatalf("expected value with expiration, got %v %v", view, err)
+	}
+	if view, _ := group.Get("long"); time.Until(view.Expire()) < 59*time.Minute {
+		t.Fatalf("default ttl not applied, expire at %v", view.Expire())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := group.Get("short"); err != nil || loadCounts["short"] != 2 {
+		t.Fatalf("expired key should be reloaded, loads = %d", loadCounts["short"])
+	}
+	if _, err := group.Get("long"); err != nil || loadCounts["long"] != 1 {
+		t.Fatalf("unexpired key should hit cache, loads = %d", loadCounts["long"])
+	}
+}
+
+// test negative caching of ErrNotFound
+func TestNegativeCache(t *testing.T) {
+	loads := 0
+	group := NewGroup("negative-scores", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			if v, ok := db[key]; ok {
+				return []byte(v), nil
+			}
+			return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+		}))
+	group.SetNegativeTTL(20 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := group.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("negative entry should be cached, loads = %d", loads)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := group.Get("missing"); !errors.Is(err, ErrNotFound) || loads != 2 {
+		t.Fatalf("negative entry should expire, loads = %d, err = %v", loads, err)
+	}
+}
+
+// fakePeer 记录收到的写入，用于测试节点间的路由
+type fakePeer struct {
+	mu      sync.Mutex
+	data    map[string]ByteView
+	hot     map[string]ByteView
+	setErr  error
+	batches int
+	// 热点推送的版本和次数，每次提升返回 hits 次命中，stale 记录乱序到达的推送
+	versions map[string]uint64
+	promotes int
+	demotes  int
+	stale    int
+	hits     uint64
+}
+
+func newFakePeer() *fakePeer {
+	return &fakePeer{data: make(map[string]ByteView), hot: make(map[string]ByteView), versions: make(map[string]uint64)}
+}
+
+func (p *fakePeer) Get(ctx context.Context, group string, key string) (ByteView, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if v, ok := p.data[key]; ok {
+		return v, nil
+	}
+	return ByteView{}, ErrNotFound
+}
+
+func (p *fakePeer) Set(ctx context.Context, group string, key string, value ByteView) error {
+	if p.setErr != nil {
+		return p.setErr
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.data[key] = value
+	return nil
+}
+
+func (p *fakePeer) Delete(ctx context.Context, group string, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.data, key)
+	return nil
+}
+
+func (p *fakePeer) BatchGet(ctx context.Context, group string, keys []string) ([]GetResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches++
+	results := make([]GetResult, len(keys))
+	for i, key := range keys {
+		if v, ok := p.data[key]; ok {
+			results[i] = GetResult{Value: v}
+		} else {
+			results[i] = GetResult{Err: ErrNotFound}
+		}
+	}
+	return results, nil
+}
+
+func (p *fakePeer) PromoteHotKey(ctx context.Context, group string, key string, value ByteView, version uint64, lease time.Duration) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if version <= p.versions[key] {
+		p.stale++
+		return 0, fmt.Errorf("stale promote of %s: version %d <= %d", key, version, p.versions[key])
+	}
+	p.versions[key] = version
+	p.promotes++
+	p.hot[key] = value
+	return p.hits, nil
+}
+
+func (p *fakePeer) DemoteHotKey(ctx context.Context, group string, key string, version uint64, lease time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if version <= p.versions[key] {
+		p.stale++
+		return fmt.Errorf("stale demote of %s: version %d <= %d", key, version, p.versions[key])
+	}
+	p.versions[key] = version
+	p.demotes++
+	delete(p.hot, key)
+	return nil
+}
+
+func (p *fakePeer) pushes() (promotes, demotes, stale int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.promotes, p.demotes, p.stale
+}
+
+func (p *fakePeer) isHot(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.hot[key]
+	return ok
+}
+
+func (p *fakePeer) has(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.data[key]
+	return ok
+}
+
+// fakePicker 将 key 路由到 owner（以 localPrefix 开头的 key 属于本节点），副本为 replicas
+type fakePicker struct {
+	owner       *fakePeer
+	replicas    []*fakePeer
+	localPrefix string
+}
+
+func (p *fakePicker) PickPeer(key string) (PeerClient, bool) {
+	if p.owner == nil || (p.localPrefix != "" && strings.HasPrefix(key, p.localPrefix)) {
+		return nil, false
+	}
+	return p.owner, true
+}
+
+func (p *fakePicker) ReplicaPeersForKey(key string) []PeerClient {
+	var peers []PeerClient
+	if p.owner != nil {
+		peers = append(peers, p.owner)
+	}
+	for _, r := range p.replicas {
+		peers = append(peers, r)
+	}
+	return peers
+}
+
+func (p *fakePicker) AllPeers() []PeerClient {
+	return p.ReplicaPeersForKey("")
+}
+
+// test Set writes to local cache when this node owns the key
+func TestSetLocal(t *testing.T) {
+	group := NewGroup("set-local", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return nil, fmt.Errorf("getter should not be called")
+		}))
+	if err := group.Set("k", []byte("v"), &SetOptions{TTL: time.Hour}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	view, err := group.Get("k")
+	if err != nil || view.String() != "v" || view.Expire().IsZero() {
+		t.Fatalf("expected cached value with ttl, got %v %v", view, err)
+	}
+}
+
+// test Set routes to the owner and fans out to replicas
+func TestSetRemote(t *testing.T) {
+	owner, replica := newFakePeer(), newFakePeer()
+	group := NewGroup("set-remote", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return nil, ErrNotFound
+		}))
+	group.RegisterPeers(&fakePicker{owner: owner, replicas: []*fakePeer{replica}})
+
+	if err := group.Set("k", []byte("v"), nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !owner.has("k") {
+		t.Fatal("owner should receive the write synchronously")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !replica.has("k") {
+		t.Fatal("replica should receive the write")
+	}
+	if view, err := group.Get("k"); err != nil || view.String() != "v" {
+		t.Fatalf("expected value from owner, got %v %v", view, err)
+	}
+
+	owner.setErr = fmt.Errorf("rejected")
+	if err := group.Set("k2", []byte("v2"), nil); err == nil {
+		t.Fatal("expected error when owner rejects the write")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if replica.has("k2") {
+		t.Fatal("replicas should not be written when owner rejects")
+	}
+}
+
+// test GetMany groups remote keys per peer and loads local misses in one batch
+func TestGetMany(t *testing.T) {
+	owner := newFakePeer()
+	owner.data["remote-1"] = ByteView{b: []byte("r1")}
+	owner.data["remote-2"] = ByteView{b: []byte("r2")}
+
+	var batches [][]string
+	group := NewGroup("get-many", 2<<20, BatchGetterFunc(
+		func(ctx context.Context, keys []string) (map[string][]byte, error) {
+			batches = append(batches, keys)
+			values := make(map[string][]byte)
+			for _, key := range keys {
+				if v, ok := db[key]; ok {
+					values[key] = []byte(v)
+				}
+			}
+			return values, nil
+		}))
+	group.RegisterPeers(&fakePicker{owner: owner, localPrefix: "local-"})
+
+	db["local-Tom"] = "630"
+	defer delete(db, "local-Tom")
+
+	keys := []string{"local-x", "local-Tom", "remote-1", "remote-2", "remote-3", "local-x"}
+	results := group.GetMany(keys)
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("expected one local batch of 2 keys, got %v", batches)
+	}
+	if owner.batches != 1 {
+		t.Fatalf("expected one batch rpc to owner, got %d", owner.batches)
+	}
+	if r := results["remote-1"]; r.Err != nil || r.Value.String() != "r1" {
+		t.Fatalf("remote-1: got %v", r)
+	}
+	if r := results["local-Tom"]; r.Err != nil || r.Value.String() != "630" {
+		t.Fatalf("local-Tom: got %v", r)
+	}
+	for _, key := range []string{"remote-3", "local-x"} {
+		if r := results[key]; !errors.Is(r.Err, ErrNotFound) {
+			t.Fatalf("%s: expected ErrNotFound, got %v", key, r.Err)
+		}
+	}
+
+	// 再次获取本地 key 应该命中缓存
+	group.GetMany([]string{"local-Tom", "local-x"})
+	if len(batches) != 1 {
+		t.Fatalf("cached keys should not be loaded again, got %v", batches)
+	}
+}
+
+// test GetMany shares in-flight loads with Get
+func TestGetManySingleflight(t *testing.T) {
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	group := NewGroup("get-many-flight", 2<<20, BatchGetterFunc(
+		func(ctx context.Context, keys []string) (map[string][]byte, error) {
+			if calls.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			values := make(map[string][]byte)
+			for _, key := range keys {
+				values[key] = []byte("v-" + key)
+			}
+			return values, nil
+		}))
+	defer group.Close()
+
+	done := make(chan map[string]GetResult)
+	go func() { done <- group.GetMany([]string{"a", "b"}) }()
+	<-started
+	// 批量加载期间的 Get 等待批量结果
+	got := make(chan string)
+	go func() {
+		v, _ := group.Get("a")
+		got <- v.String()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if r := <-done; r["a"].Value.String() != "v-a" || r["b"].Value.String() != "v-b" {
+		t.Fatalf("unexpected results %v", r)
+	}
+	if v := <-got; v != "v-a" || calls.Load() != 1 {
+		t.Fatalf("Get = %q, getter called %d times", v, calls.Load())
+	}
+}
+
+// test a panicking BatchGetter still completes keys that Get is waiting on
+func TestGetManyBatchPanic(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	group := NewGroup("get-many-panic", 2<<20, BatchGetterFunc(
+		func(ctx context.Context, keys []string) (map[string][]byte, error) {
+			close(started)
+			<-release
+			panic("boom")
+		}))
+	defer group.Close()
+
+	done := make(chan map[string]GetResult)
+	go func() { done <- group.GetMany([]string{"a", "b"}) }()
+	<-started
+	got := make(chan error)
+	go func() {
+		_, err := group.Get("a")
+		got <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if r := <-done; r["a"].Err == nil || r["b"].Err == nil {
+		t.Fatalf("expected errors after panic, got %v", r)
+	}
+	select {
+	case err := <-got:
+		if err == nil {
+			t.Fatal("expected Get to fail after the batch panicked")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get blocked after the batch panicked")
+	}
+}
+
+// batchTTLGetter 同时实现 BatchGetter 和 TTLGetter
+type batchTTLGetter struct {
+	batches atomic.Int32
+}
+
+func (g *batchTTLGetter) Get(key string) ([]byte, error) {
+	return []byte(key), nil
+}
+
+func (g *batchTTLGetter) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	return []byte(key), time.Minute, nil
+}
+
+func (g *batchTTLGetter) GetMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	g.batches.Add(1)
+	return nil, nil
+}
+
+// test GetMany keeps per-key TTLs by loading keys one by one for a TTLGetter
+func TestGetManyTTLGetter(t *testing.T) {
+	getter := &batchTTLGetter{}
+	group := NewGroup("get-many-ttl", 2<<20, getter)
+	defer group.Close()
+
+	results := group.GetMany([]string{"a", "b"})
+	if getter.batches.Load() != 0 {
+		t.Fatal("TTLGetter should not be loaded in batches")
+	}
+	for _, key := range []string{"a", "b"} {
+		if r := results[key]; r.Err != nil || r.Value.Expire().IsZero() {
+			t.Fatalf("%s: expected value with TTL, got %+v", key, r)
+		}
+	}
+}
+
+// test Close and DestroyGroup stop the group and unregister it
+func TestGroupClose(t *testing.T) {
+	group := NewGroup("closing", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(key), nil
+		}))
+	if _, err := group.Get("k"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	DestroyGroup("closing")
+	if GetGroup("closing") != nil {
+		t.Fatal("closed group should be unregistered")
+	}
+	select {
+	case <-group.mainCache.hotDetector.stopCh:
+	default:
+		t.Fatal("hot key detector should be stopped")
+	}
+	if _, err := group.Get("k"); !errors.Is(err, ErrGroupClosed) {
+		t.Fatalf("expected ErrGroupClosed, got %v", err)
+	}
+	if err := group.Set("k", []byte("v"), nil); !errors.Is(err, ErrGroupClosed) {
+		t.Fatalf("expected ErrGroupClosed, got %v", err)
+	}
+	if r := group.GetMany([]string{"k"}); !errors.Is(r["k"].Err, ErrGroupClosed) {
+		t.Fatalf("expected ErrGroupClosed, got %v", r["k"].Err)
+	}
+	group.Close()
+}
+
+// test stale-while-revalidate returns the cached value and refreshes in background
+func TestRefreshAhead(t *testing.T) {
+	var mu sync.Mutex
+	version := 0
+	group := NewGroup("refresh-ahead", 2<<20, GetterFunc(
+		func(key string) ([]byte, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			version++
+			return []byte(fmt.Sprintf("v%d", version)), nil
+		}))
+	group.SetDefaultTTL(200 * time.Millisecond)
+	group.SetRefreshAhead(150 * time.Millisecond)
+
+	if view, err := group.Get("k"); err != nil || view.String() != "v1" {
+		t.Fatalf("unexpected value %v %v", view, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	// 进入刷新窗口，仍然返回旧值
+	for i := 0; i < 5; i++ {
+		if view, err := group.Get("k"); err != nil || view.String() != "v1" {
+			t.Fatalf("expected stale value, got %v %v", view, err)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+	if view, err := group.Get("k"); err != nil || view.String() != "v2" {
+		t.Fatalf("expected refreshed value, got %v %v", view, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if version != 2 {
+		t.Fatalf("expected a single background reload, got %d loads", version)
+	}
+}
+
+// test bloom filter guard rejects unknown keys before calling the getter
+func TestBloomFilterGuard(t *testing.T) {
+	loads := 0
+	group := NewGroup("bloom-scores", 2<<20, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			if v, ok := db[key]; ok {
+				return []byte(v), nil
+			}
+			return nil, ErrNotFound
+		}))
+	group.EnableBloomFilter(1000, 0.01, "Tom", "Jack")
+	group.SetBloomFilterComplete(true)
+
+	if view, err := group.Get("Tom"); err != nil || view.String() != "630" {
+		t.Fatalf("expected seeded key to load, got %v %v", view, err)
+	}
+	if _, err := group.Get("Sam"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for key outside filter, got %v", err)
+	}
+	if loads != 1 {
+		t.Fatalf("getter should only be called for keys in filter, loads = %d", loads)
+	}
+
+	// 写入的 key 会被加入过滤器
+	if err := group.Set("new", []byte("1"), nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	group.Delete("new")
+	db["new"] = "1"
+	defer delete(db, "new")
+	if view, err := group.Get("new"); err != nil || view.String() != "1" {
+		t.Fatalf("expected written key to pass the filter, got %v %v", view, err)
+	}
+}
+
+// test an incomplete bloom filter lets misses through and learns loaded keys
+func TestBloomFilterLearn(t *testing.T) {
+	owner := newFakePeer()
+	owner.data["remote-a"] = ByteView{b: []byte("r")}
+	group := NewGroupWithOptions("bloom-learn", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("v"), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithBloomFilter(1000, 0.01),
+		WithPeerPicker(&fakePicker{owner: owner, localPrefix: "local-"}),
+	)
+	defer group.Close()
+
+	for _, key := range []string{"local-a", "remote-a"} {
+		if _, err := group.Get(key); err != nil {
+			t.Fatalf("%s: expected miss to reach the loader, got %v", key, err)
+		}
+		if !group.bloom.Test(key) {
+			t.Fatalf("%s: loaded key should be added to the filter", key)
+		}
+	}
+
+	complete := NewGroupWithOptions("bloom-complete", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("v"), nil
+		}),
+		WithCompleteBloomFilter(1000, 0.01, "k"),
+	)
+	defer complete.Close()
+	if _, err := complete.Get("other"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("complete filter should reject unknown keys, got %v", err)
+	}
+}
+
+// test NewGroupWithOptions applies options on top of defaults
+func TestNewGroupWithOptions(t *testing.T) {
+	picker := &fakePicker{}
+	group := NewGroupWithOptions("options", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(key), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(3),
+		WithShardCount(16),
+		WithTTL(time.Minute),
+		WithRefreshAhead(time.Second),
+		WithBloomFilter(100, 0.01, "k"),
+		WithPeerPicker(picker),
+	)
+	defer group.Close()
+
+	if group.mainCache.cacheBytes.Load() != 1<<20 || len(group.mainCache.shards) != 16 {
+		t.Fatalf("cache options not applied: %d bytes, %d shards", group.mainCache.cacheBytes.Load(), len(group.mainCache.shards))
+	}
+	if group.mainCache.hotDetector.threshold != 3 || group.mainCache.hotDetector.decayIntv != DefaultDecayInterval {
+		t.Fatal("hot key options not applied")
+	}
+	if group.ttl != time.Minute || group.refreshAhead != time.Second || group.negativeTTL != DefaultNegativeTTL {
+		t.Fatal("ttl options not applied")
+	}
+	if group.bloom == nil || !group.bloom.Test("k") {
+		t.Fatal("bloom filter option not applied")
+	}
+	if group.peers != picker {
+		t.Fatal("peer picker option not applied")
+	}
+	if view, err := group.Get("k"); err != nil || view.Expire().IsZero() {
+		t.Fatalf("unexpected value %v %v", view, err)
+	}
+}
+
+// test every built-in eviction policy works as shard storage and honors the byte limit
+func TestEvictionPolicies(t *testing.T) {
+	policies := map[string]PolicyFactory{
+		"lru":   LRUPolicy,
+		"lfu":   LFUPolicy,
+		"arc":   ARCPolicy,
+		"sieve": SIEVEPolicy,
+		"slab":  SlabPolicy,
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			group := NewGroupWithOptions("policy-"+name, GetterFunc(
+				func(key string) ([]byte, error) {
+					return []byte("value-" + key), nil
+				}),
+				WithCacheBytes(4<<10),
+				WithShardCount(4),
+				WithEvictionPolicy(policy),
+			)
+			defer group.Close()
+
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key-%d", i)
+				if view, err := group.Get(key); err != nil || view.String() != "value-"+key {
+					t.Fatalf("%s: unexpected value %v %v", key, view, err)
+				}
+			}
+			var total int64
+			for _, shard := range group.mainCache.shards {
+				total += shard.policy.NBytes()
+			}
+			if total > 4<<10 || total != group.mainCache.nbytes.Load() {
+				t.Fatalf("cache exceeds its byte limit: %d (counter %d)", total, group.mainCache.nbytes.Load())
+			}
+		})
+	}
+}
+
+func TestTinyLFUAdmission(t *testing.T) {
+	c := newCacheWithOptions(cacheOptions{
+		cacheBytes:    100,
+		hotThreshold:  DefaultHotKeyThreshold,
+		decayInterval: DefaultDecayInterval,
+		shardCount:    1,
+		admission:     true,
+	})
+	defer c.close()
+	shard := c.shards[0]
+	value := ByteView{b: []byte("vvvvvvvv")}
+
+	hot := []string{"h0", "h1", "h2", "h3", "h4"}
+	for _, key := range hot {
+		c.add(key, value)
+		for i := 0; i < 5; i++ {
+			c.get(key)
+		}
+	}
+	// 一次性访问的 key 不应挤掉高频 key
+	for i := 0; i < 100; i++ {
+		c.add(fmt.Sprintf("s%03d", i), value)
+	}
+	for _, key := range hot {
+		if _, ok := shard.policy.Peek(key); !ok {
+			t.Fatalf("hot key %s evicted by scan", key)
+		}
+	}
+
+	// 访问足够频繁的新 key 最终会被接纳
+	admitted := false
+	for i := 0; i < 20 && !admitted; i++ {
+		c.add("new", value)
+		_, admitted = shard.policy.Peek("new")
+	}
+	if !admitted {
+		t.Fatal("frequent key should be admitted")
+	}
+
+	// 显式写入不经过准入，总是生效
+	c.addWith("explicit", value, addExplicit)
+	if _, ok := shard.policy.Peek("explicit"); !ok {
+		t.Fatal("explicit write should bypass admission")
+	}
+}
+
+// test admission compares against the victim of the shard global eviction would use
+func TestTinyLFUAdmissionAcrossShards(t *testing.T) {
+	c := newCacheWithOptions(cacheOptions{
+		cacheBytes:    100,
+		hotThreshold:  DefaultHotKeyThreshold,
+		decayInterval: DefaultDecayInterval,
+		shardCount:    2,
+		// h 开头的 key 在分片 0，其他 key 在分片 1
+		hash: func(key string) uint32 {
+			if strings.HasPrefix(key, "h") {
+				return 0
+			}
+			return 1
+		},
+		admission: true,
+	})
+	defer c.close()
+	value := ByteView{b: []byte("vvvvvvvv")}
+
+	var hot []string
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("h%d", i)
+		hot = append(hot, key)
+		c.add(key, value)
+		for j := 0; j < 5; j++ {
+			c.get(key)
+		}
+	}
+	// 分片 1 是空的，但总容量已满，接纳一次性访问的 key 会淘汰分片 0 中的高频 key
+	for i := 0; i < 20; i++ {
+		c.add(fmt.Sprintf("s%03d", i), value)
+	}
+	for _, key := range hot {
+		if _, ok := c.shards[0].policy.Peek(key); !ok {
+			t.Fatalf("hot key %s evicted by a key in another shard", key)
+		}
+	}
+}
+
+func TestOnEvict(t *testing.T) {
+	group := NewGroupWithOptions("evict-hooks", GetterFunc(
+		func(key string) ([]byte, error) {
+			return nil, ErrNotFound
+		}),
+		WithCacheBytes(30),
+		WithShardCount(1),
+	)
+	defer group.Close()
+
+	var events []string
+	group.OnEvict(func(key string, value ByteView, reason EvictReason) {
+		events = append(events, fmt.Sprintf("%s=%s:%s", key, value, reason))
+	})
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if err := group.Set(key, []byte("123456789"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	group.Set("b", []byte("987654321"), nil)
+	group.Delete("c")
+	group.Set("e", []byte("123456789"), &SetOptions{TTL: 10 * time.Millisecond})
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := group.mainCache.get("e"); ok {
+		t.Fatal("expired entry should miss")
+	}
+
+	expect := []string{
+		"a=123456789:capacity",
+		"b=123456789:replaced",
+		"c=123456789:deleted",
+		"e=123456789:expired",
+	}
+	if !reflect.DeepEqual(events, expect) {
+		t.Fatalf("events = %v, want %v", events, expect)
+	}
+}
+
+func TestGlobalBudget(t *testing.T) {
+	// 1KB 分给 256 个分片每片只有 4 字节，按总容量计算时仍然可以缓存 50 个 20 字节的条目
+	c := newCache(1<<10, DefaultHotKeyThreshold, DefaultDecayInterval)
+	defer c.close()
+	value := ByteView{b: []byte("0123456789abcdef")}
+
+	for i := 0; i < 50; i++ {
+		c.add(fmt.Sprintf("k%03d", i), value)
+	}
+	for i := 0; i < 50; i++ {
+		if _, ok := c.get(fmt.Sprintf("k%03d", i)); !ok {
+			t.Fatalf("k%03d should be cached", i)
+		}
+	}
+
+	for i := 50; i < 200; i++ {
+		c.add(fmt.Sprintf("k%03d", i), value)
+	}
+	var total int64
+	var n int
+	for _, shard := range c.shards {
+		total += shard.policy.NBytes()
+		n += shard.policy.Len()
+	}
+	if total != c.nbytes.Load() {
+		t.Fatalf("byte counter %d, shards hold %d", c.nbytes.Load(), total)
+	}
+	if total > 1<<10 || n != 51 {
+		t.Fatalf("cache holds %d entries (%d bytes), want 51 entries within 1KB", n, total)
+	}
+}
+
+func TestCacheBudget(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return []byte("0123456789"), nil
+	})
+
+	budget := NewCacheBudget(1000, BudgetByWeight, time.Hour)
+	defer budget.Stop()
+	a := NewGroupWithOptions("budget-a", getter, WithCacheBudget(budget, 3))
+	b := NewGroupWithOptions("budget-b", getter, WithCacheBudget(budget, 1))
+	defer b.Close()
+	if a.mainCache.cacheBytes.Load() != 750 || b.mainCache.cacheBytes.Load() != 250 {
+		t.Fatalf("shares = %d/%d, want 750/250", a.mainCache.cacheBytes.Load(), b.mainCache.cacheBytes.Load())
+	}
+	for i := 0; i < 100; i++ {
+		b.Get(fmt.Sprintf("k%02d", i))
+	}
+	if n := b.mainCache.nbytes.Load(); n > 250 {
+		t.Fatalf("group b uses %d bytes beyond its share", n)
+	}
+	a.Close()
+	if b.mainCache.cacheBytes.Load() != 1000 {
+		t.Fatalf("share after leave = %d, want 1000", b.mainCache.cacheBytes.Load())
+	}
+
+	hitBudget := NewCacheBudget(1000, BudgetByHitValue, time.Hour)
+	defer hitBudget.Stop()
+	hot := NewGroupWithOptions("budget-hot", getter, WithCacheBudget(hitBudget, 1))
+	defer hot.Close()
+	cold := NewGroupWithOptions("budget-cold", getter, WithCacheBudget(hitBudget, 1))
+	defer cold.Close()
+	for i := 0; i < 10; i++ {
+		hot.Get("key")
+	}
+	hitBudget.Rebalance()
+	if hot.mainCache.cacheBytes.Load() <= cold.mainCache.cacheBytes.Load() {
+		t.Fatalf("hot group share %d should exceed cold group share %d",
+			hot.mainCache.cacheBytes.Load(), cold.mainCache.cacheBytes.Load())
+	}
+}
+
+// test eviction caused by rebalancing runs outside the budget lock and resizes the hot tier
+func TestCacheBudgetRebalanceEvict(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return []byte("0123456789"), nil
+	})
+	budget := NewCacheBudget(1000, BudgetByWeight, time.Hour)
+	defer budget.Stop()
+	a := NewGroupWithOptions("budget-evict-a", getter, WithCacheBudget(budget, 1))
+	defer a.Close()
+	if max := a.mainCache.hotDetector.maxBytes; max != 1000/DefaultHotKeyBytesRatio {
+		t.Fatalf("hot tier limit = %d, want %d", max, 1000/DefaultHotKeyBytesRatio)
+	}
+	for i := 0; i < 100; i++ {
+		a.Get(fmt.Sprintf("k%02d", i))
+	}
+
+	// 淘汰回调中访问预算不会死锁
+	var evicted atomic.Int32
+	a.OnEvict(func(key string, value ByteView, reason EvictReason) {
+		evicted.Add(1)
+		budget.Rebalance()
+	})
+	joined := make(chan *Group)
+	go func() {
+		joined <- NewGroupWithOptions("budget-evict-b", getter, WithCacheBudget(budget, 3))
+	}()
+	select {
+	case b := <-joined:
+		defer b.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("rebalance deadlocked in eviction hook")
+	}
+	if evicted.Load() == 0 {
+		t.Fatal("shrinking the share should evict entries")
+	}
+	if max := a.mainCache.hotDetector.maxBytes; max != 250/DefaultHotKeyBytesRatio {
+		t.Fatalf("hot tier limit = %d after rebalance, want %d", max, 250/DefaultHotKeyBytesRatio)
+	}
+}
+
+func TestShardCountAndHash(t *testing.T) {
+	var calls int
+	hash := func(key string) uint32 {
+		calls++
+		return FNV1aHash(key)
+	}
+	group := NewGroupWithOptions("shards", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(key), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithShardCount(100),
+		WithHashFunc(hash),
+	)
+	defer group.Close()
+
+	if n := len(group.mainCache.shards); n != 128 {
+		t.Fatalf("shard count = %d, want 128", n)
+	}
+	if _, err := group.Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	if calls == 0 {
+		t.Fatal("custom hash func was not used")
+	}
+	if FNV1aHash("key") != stdFNV1a("key") {
+		t.Fatal("FNV1aHash should match hash/fnv")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { group.mainCache.getShard("some-key") }); allocs != 0 {
+		t.Fatalf("getShard allocates %v times", allocs)
+	}
+}
+
+// stdFNV1a 用标准库计算 FNV-1a，用来校验 FNV1aHash
+func stdFNV1a(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+func TestPeekAndEntryInfo(t *testing.T) {
+	group := NewGroupWithOptions("peek", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("0123456789"), nil
+		}),
+		WithCacheBytes(30),
+		WithShardCount(1),
+		WithTTL(time.Minute),
+		WithPeerPicker(&fakePicker{owner: newFakePeer(), localPrefix: "l"}),
+	)
+	defer group.Close()
+
+	if group.Contains("la") {
+		t.Fatal("la should not be cached yet")
+	}
+	group.Get("la")
+	group.Get("la")
+	group.Get("lb")
+	count := group.mainCache.hotDetector.cms.Count("la")
+	if v, ok := group.Peek("la"); !ok || v.String() != "0123456789" {
+		t.Fatalf("Peek(la) = %v, %v", v, ok)
+	}
+	if c := group.mainCache.hotDetector.cms.Count("la"); c != count {
+		t.Fatalf("Peek recorded a hot key access: %d -> %d", count, c)
+	}
+
+	info, ok := group.EntryInfo("la")
+	if !ok {
+		t.Fatal("EntryInfo(la) not found")
+	}
+	if info.Size != 12 || info.AccessCount != 1 || info.Owner != "" || info.Hot {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if info.TTL <= 0 || info.TTL > time.Minute || info.LastAccess.Before(info.Inserted) {
+		t.Fatalf("unexpected times %+v", info)
+	}
+
+	// Peek 不会提升 la，新条目写满容量时 la 仍是最久未使用的
+	group.Get("lc")
+	if group.Contains("la") || !group.Contains("lb") || !group.Contains("lc") {
+		t.Fatal("Peek should not change eviction order")
+	}
+
+	group.setCache("remote", ByteView{b: []byte("v")})
+	if info, ok := group.EntryInfo("remote"); !ok || info.Owner != "remote" {
+		t.Fatalf("EntryInfo(remote) = %+v, %v", info, ok)
+	}
+}
+
+func TestRangeAndKeys(t *testing.T) {
+	group := NewGroupWithOptions("range", GetterFunc(
+		func(key string) ([]byte, error) {
+			if strings.HasPrefix(key, "missing") {
+				return nil, ErrNotFound
+			}
+			return []byte("v"), nil
+		}),
+		WithCacheBytes(1<<20),
+	)
+	defer group.Close()
+
+	for i := 0; i < 30; i++ {
+		group.Get(fmt.Sprintf("user:%02d", i))
+		group.Get(fmt.Sprintf("order:%02d", i))
+	}
+	group.Get("missing")
+
+	var n int
+	group.Range(func(key string, value ByteView) bool {
+		// 回调中访问缓存不会死锁
+		if _, ok := group.Peek(key); !ok || key == "missing" {
+			t.Fatalf("unexpected key %s", key)
+		}
+		n++
+		return true
+	})
+	if n != 60 {
+		t.Fatalf("Range visited %d entries, want 60", n)
+	}
+
+	keys := group.Keys("user:")
+	if len(keys) != 30 || keys[0] != "user:00" || keys[29] != "user:29" {
+		t.Fatalf("Keys(user:) = %v", keys)
+	}
+
+	// 调试接口分页
+	ms := NewMetricsServer("")
+	var pages [][]string
+	after := ""
+	for {
+		rec := httptest.NewRecorder()
+		ms.keysHandler(rec, httptest.NewRequest("GET", "/debug/keys?group=range&prefix=user:&limit=12&after="+after, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var page struct {
+			Keys []string `json:"keys"`
+			Next string   `json:"next"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page.Keys)
+		if page.Next == "" {
+			break
+		}
+		after = page.Next
+	}
+	var paged []string
+	for _, page := range pages {
+		if len(page) > 12 {
+			t.Fatalf("page has %d keys, limit 12", len(page))
+		}
+		paged = append(paged, page...)
+	}
+	sort.Strings(paged)
+	if len(pages) < 3 || !reflect.DeepEqual(paged, keys) {
+		t.Fatalf("unexpected pages %v", pages)
+	}
+
+	rec := httptest.NewRecorder()
+	ms.keysHandler(rec, httptest.NewRequest("GET", "/debug/keys?group=nope", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown group status = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	ms.keysHandler(rec, httptest.NewRequest("GET", "/debug/keys?group=range&after=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid cursor status = %d", rec.Code)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return nil, ErrNotFound
+	})
+	src := NewGroupWithOptions("snapshot-src", getter, WithCacheBytes(1<<20), WithShardCount(1))
+	defer src.Close()
+	for i := 0; i < 5; i++ {
+		src.Set(fmt.Sprintf("k%d", i), []byte(fmt.Sprintf("v%d", i)), nil)
+	}
+	src.Set("ttl", []byte("t"), &SetOptions{TTL: time.Hour})
+	src.Set("gone", []byte("g"), &SetOptions{TTL: time.Millisecond})
+	src.Get("missing") // 负缓存不写入快照
+	src.Get("k0")      // k0 变为最近使用
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := src.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	dst := NewGroupWithOptions("snapshot-dst", getter, WithCacheBytes(1<<20), WithShardCount(1), WithTinyLFUAdmission())
+	defer dst.Close()
+	n, err := dst.LoadSnapshot(bytes.NewReader(data))
+	if err != nil || n != 6 {
+		t.Fatalf("LoadSnapshot = %d, %v", n, err)
+	}
+	// 恢复不算访问，不影响热点和准入的频率统计
+	if c := dst.mainCache.hotDetector.cms.Count("k0"); c != 0 {
+		t.Fatalf("restore recorded %d hot-key accesses", c)
+	}
+	if e := dst.mainCache.admission.estimate("k0"); e != 0 {
+		t.Fatalf("restore recorded admission frequency %d", e)
+	}
+	var order []string
+	dst.Range(func(key string, value ByteView) bool {
+		order = append(order, key)
+		return true
+	})
+	expect := []string{"k1", "k2", "k3", "k4", "ttl", "k0"}
+	if !reflect.DeepEqual(order, expect) {
+		t.Fatalf("order = %v, want %v", order, expect)
+	}
+	if info, ok := dst.EntryInfo("ttl"); !ok || info.TTL <= 0 || info.TTL > time.Hour {
+		t.Fatalf("TTL not preserved: %+v", info)
+	}
+	if v, _ := dst.Peek("k3"); v.String() != "v3" {
+		t.Fatalf("k3 = %q", v.String())
+	}
+
+	// 损坏的快照不会写入任何条目
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)/2] ^= 0xff
+	bad := NewGroupWithOptions("snapshot-bad", getter, WithCacheBytes(1<<20))
+	defer bad.Close()
+	if _, err := bad.LoadSnapshot(bytes.NewReader(corrupt)); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Fatalf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+	if len(bad.Keys("")) != 0 {
+		t.Fatal("corrupt snapshot should not load entries")
+	}
+
+	// 配置快照路径后，SaveSnapshots 写入文件，新建 Group 时自动加载
+	path := filepath.Join(t.TempDir(), "warm.snap")
+	warm := NewGroupWithOptions("snapshot-warm", getter, WithCacheBytes(1<<20), WithSnapshotPath(path))
+	warm.Set("a", []byte("1"), nil)
+	if err := SaveSnapshots(); err != nil {
+		t.Fatal(err)
+	}
+	warm.Close()
+	restarted := NewGroupWithOptions("snapshot-warm", getter, WithCacheBytes(1<<20), WithSnapshotPath(path))
+	defer restarted.Close()
+	if v, ok := restarted.Peek("a"); !ok || v.String() != "1" {
+		t.Fatalf("warm restart lost entry: %q, %v", v.String(), ok)
+	}
+}
+
+func TestDiskTier(t *testing.T) {
+	var loads atomic.Int32
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		loads.Add(1)
+		return []byte("0123456789"), nil
+	})
+	dir := t.TempDir()
+	group := NewGroupWithOptions("disk-tier", getter,
+		WithCacheBytes(100),
+		WithShardCount(1),
+		WithDiskTier(diskcache.Options{Dir: dir, MaxBytes: 1 << 20}),
+	)
+
+	for i := 0; i < 20; i++ {
+		group.Get(fmt.Sprintf("k%02d", i))
+	}
+	if group.mainCache.disk.Len() == 0 {
+		t.Fatal("evicted entries should be spilled to disk")
+	}
+	// 内存放不下的条目从磁盘读取，不再回源
+	for i := 0; i < 20; i++ {
+		if v, err := group.Get(fmt.Sprintf("k%02d", i)); err != nil || v.String() != "0123456789" {
+			t.Fatalf("k%02d = %q, %v", i, v.String(), err)
+		}
+	}
+	if n := loads.Load(); n != 20 {
+		t.Fatalf("getter called %d times, want 20", n)
+	}
+	// 所有 key 都已经在磁盘上，再次读回和淘汰都不写磁盘
+	size := group.mainCache.disk.Size()
+	for i := 0; i < 20; i++ {
+		group.Get(fmt.Sprintf("k%02d", i))
+	}
+	if group.mainCache.disk.Size() != size {
+		t.Fatalf("promotions rewrote the disk tier: %d -> %d bytes", size, group.mainCache.disk.Size())
+	}
+
+	// 删除同时作用于磁盘层
+	onDisk := ""
+	for i := 0; i < 20 && onDisk == ""; i++ {
+		if _, _, ok := group.mainCache.disk.Get(fmt.Sprintf("k%02d", i)); ok {
+			onDisk = fmt.Sprintf("k%02d", i)
+		}
+	}
+	group.Delete(onDisk)
+	group.Get(onDisk)
+	if n := loads.Load(); n != 21 {
+		t.Fatalf("deleted key should be reloaded from getter, loads = %d", n)
+	}
+	group.Close()
+
+	// 磁盘层在重启后仍然可用
+	reopened := NewGroupWithOptions("disk-tier", getter,
+		WithCacheBytes(100),
+		WithDiskTier(diskcache.Options{Dir: dir, MaxBytes: 1 << 20}),
+	)
+	defer reopened.Close()
+	if reopened.mainCache.disk.Len() == 0 {
+		t.Fatal("disk tier should survive restart")
+	}
+}
+
+func TestCompression(t *testing.T) {
+	large := bytes.Repeat([]byte("distcache "), 100)
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return large, nil
+	})
+	for _, codec := range []Compression{CompressionGzip, CompressionFlate} {
+		group := NewGroupWithOptions("compression-"+codec.String(), getter,
+			WithCacheBytes(1<<20), WithCompression(codec, 64))
+
+		// 从数据源加载的值和 Set 写入的值都会被压缩
+		v, err := group.Get("loaded")
+		if err != nil || !bytes.Equal(v.ByteSlice(), large) || v.Len() != len(large) {
+			t.Fatalf("%v: Get = %d bytes, %v", codec, v.Len(), err)
+		}
+		group.Set("set", large, nil)
+		group.Set("small", []byte("tiny"), nil)
+		for _, key := range []string{"loaded", "set"} {
+			info, ok := group.EntryInfo(key)
+			if !ok || info.Size >= int64(len(key)+len(large)) {
+				t.Fatalf("%v: %s not compressed: %+v", codec, key, info)
+			}
+			if v, _ := group.Peek(key); v.codec != codec || v.String() != string(large) {
+				t.Fatalf("%v: %s = codec %v", codec, key, v.codec)
+			}
+		}
+		// 小于阈值的值不压缩
+		if v, _ := group.Peek("small"); v.codec != CompressionNone || v.String() != "tiny" {
+			t.Fatalf("%v: small value should not be compressed", codec)
+		}
+
+		// 快照保留压缩方式
+		var buf bytes.Buffer
+		if err := group.SaveSnapshot(&buf); err != nil {
+			t.Fatal(err)
+		}
+		dst := NewGroupWithOptions("compression-dst-"+codec.String(), getter, WithCacheBytes(1<<20))
+		if n, err := dst.LoadSnapshot(&buf); err != nil || n != 3 {
+			t.Fatalf("%v: LoadSnapshot = %d, %v", codec, n, err)
+		}
+		if v, _ := dst.Peek("set"); v.codec != codec || !bytes.Equal(v.ByteSlice(), large) {
+			t.Fatalf("%v: snapshot lost codec", codec)
+		}
+		dst.Close()
+		group.Close()
+	}
+
+	// 压缩后没有变小的值按原样保存
+	c := compressor{codec: CompressionGzip, threshold: 1}
+	if v := c.compress(ByteView{b: []byte("ab")}); v.codec != CompressionNone || string(v.b) != "ab" {
+		t.Fatalf("incompressible value should be kept raw, got codec %v", v.codec)
+	}
+
+	// Len 使用保存的长度，不需要解压；解压失败时通过 Decode 返回错误
+	corrupt := c.compress(ByteView{b: large})
+	corrupt.b = append([]byte(nil), corrupt.b[:len(corrupt.b)/2]...)
+	if corrupt.Len() != len(large) {
+		t.Fatalf("Len = %d, want %d", corrupt.Len(), len(large))
+	}
+	if b, err := corrupt.Decode(); err == nil || b != nil || corrupt.String() != "" {
+		t.Fatalf("corrupt value should fail to decode, got %d bytes, %v", len(b), err)
+	}
+
+	// BatchGetter 加载的值同样会被压缩
+	batch := NewGroupWithOptions("compression-batch", BatchGetterFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+		values := make(map[string][]byte, len(keys))
+		for _, key := range keys {
+			values[key] = large
+		}
+		return values, nil
+	}), WithCacheBytes(1<<20), WithCompression(CompressionFlate, 64))
+	defer batch.Close()
+	for key, r := range batch.GetMany([]string{"a", "b"}) {
+		if r.Err != nil || r.Value.codec != CompressionFlate || r.Value.Len() != len(large) || !bytes.Equal(r.Value.ByteSlice(), large) {
+			t.Fatalf("batch %s: codec %v, %d bytes, %v", key, r.Value.codec, r.Value.Len(), r.Err)
+		}
+	}
+}
+
+func TestSlabPolicy(t *testing.T) {
+	group := NewGroupWithOptions("slab-policy", GetterFunc(
+		func(key string) ([]byte, error) {
+			return nil, ErrNotFound
+		}),
+		WithCacheBytes(1<<20),
+		WithEvictionPolicy(SlabPolicy),
+		WithCompression(CompressionFlate, 64),
+	)
+	defer group.Close()
+
+	large := strings.Repeat("slab ", 100)
+	group.Set("k", []byte(large), &SetOptions{TTL: time.Hour})
+	for i := 0; i < 2; i++ {
+		if v, err := group.Get("k"); err != nil || v.String() != large {
+			t.Fatalf("Get = %d bytes, %v", v.Len(), err)
+		}
+	}
+	// 访问信息、过期时间和压缩方式都保存在 slab 中
+	info, ok := group.EntryInfo("k")
+	if !ok || info.AccessCount != 2 || info.LastAccess.Before(info.Inserted) || info.TTL <= 0 {
+		t.Fatalf("unexpected entry info: %+v", info)
+	}
+	if v, _ := group.Peek("k"); v.codec != CompressionFlate {
+		t.Fatalf("codec = %v, want flate", v.codec)
+	}
+	// 负缓存同样保存在 slab 中
+	if _, err := group.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, ok := group.mainCache.peekEntry("missing"); !ok {
+		t.Fatal("negative entry should be cached")
+	}
+	if group.Contains("missing") {
+		t.Fatal("negative entry should not be visible")
+	}
+	// 分页列出 key 时不复制 slab 中的值
+	if keys, next, err := group.keysPage("", "", 10); err != nil || !reflect.DeepEqual(keys, []string{"k"}) || next != "" {
+		t.Fatalf("keysPage = %v %q %v", keys, next, err)
+	}
+}
+
+func TestHotKeys(t *testing.T) {
+	group := NewGroupWithOptions("hot-keys", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("v"), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(20),
+	)
+	defer group.Close()
+
+	// a 会进入热点层，之后的命中由热点层计数
+	for key, n := range map[string]int{"a": 50, "b": 10, "c": 5, "d": 1} {
+		for i := 0; i < n; i++ {
+			group.Get(key)
+		}
+	}
+	keys := group.HotKeys(3)
+	if len(keys) != 3 || keys[0].Key != "a" || keys[1].Key != "b" || keys[2].Key != "c" {
+		t.Fatalf("unexpected hot keys %+v", keys)
+	}
+	if keys[0].Count != 50 || !keys[0].Hot || keys[1].Count != 10 || keys[1].Hot {
+		t.Fatalf("unexpected counts %+v", keys)
+	}
+
+	ms := NewMetricsServer("")
+	rec := httptest.NewRecorder()
+	ms.hotKeysHandler(rec, httptest.NewRequest("GET", "/debug/hotkeys?group=hot-keys&n=2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Group string       `json:"group"`
+		Keys  []HotKeyInfo `json:"keys"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Group != "hot-keys" || !reflect.DeepEqual(resp.Keys, keys[:2]) {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	ms.hotKeysHandler(rec, httptest.NewRequest("GET", "/debug/hotkeys?group=hot-keys&n=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid n status = %d", rec.Code)
+	}
+}
+
+// waitFor 轮询等待异步操作完成
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// test hot keys are broadcast by the owner and pinned on other nodes
+func TestHotKeyReplication(t *testing.T) {
+	owner, replica := newFakePeer(), newFakePeer()
+	owner.data["remote-a"] = ByteView{b: []byte("remote")}
+	picker := &fakePicker{owner: owner, replicas: []*fakePeer{replica}, localPrefix: "local-"}
+	var loads atomic.Int32
+	group := NewGroupWithOptions("hot-replication", GetterFunc(
+		func(key string) ([]byte, error) {
+			loads.Add(1)
+			return []byte("v-" + key), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(5),
+		WithPeerPicker(picker),
+		WithHotKeyReplication(),
+	)
+	defer group.Close()
+
+	// 本节点是主节点，热点广播给所有节点
+	for i := 0; i < 10; i++ {
+		group.Get("local-a")
+	}
+	waitFor(t, "promote broadcast", func() bool { return owner.isHot("local-a") && replica.isHot("local-a") })
+
+	// 删除时撤销所有节点上的热点
+	group.Delete("local-a")
+	waitFor(t, "demote broadcast", func() bool { return !owner.isHot("local-a") && !replica.isHot("local-a") })
+
+	// 主节点推送的热点直接在本地提供，不再访问主节点
+	group.pinHotKey("remote-b", ByteView{b: []byte("pinned")}, 1, time.Minute)
+	if v, err := group.Get("remote-b"); err != nil || v.String() != "pinned" {
+		t.Fatalf("pinned key = %q, %v", v.String(), err)
+	}
+	group.unpinHotKey("remote-b", 2, time.Minute)
+	if group.mainCache.isHot("remote-b") {
+		t.Fatal("remote-b should be unpinned")
+	}
+
+	// 非主节点的热点不广播
+	for i := 0; i < 10; i++ {
+		group.Get("remote-a")
+	}
+	group.pinHotKey("remote-a", ByteView{b: []byte("remote")}, 1, time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	if owner.isHot("remote-a") || replica.isHot("remote-a") {
+		t.Fatal("non-owner should not broadcast hot keys")
+	}
+	if loads.Load() != 1 {
+		t.Fatalf("getter called %d times, want 1", loads.Load())
+	}
+}
+
+// test only owned, pushed keys are broadcast: negatives, non-owned keys and cold deletes stay local
+func TestHotKeyReplicationDemote(t *testing.T) {
+	owner, replica := newFakePeer(), newFakePeer()
+	picker := &fakePicker{owner: owner, replicas: []*fakePeer{replica}, localPrefix: "local-"}
+	group := NewGroupWithOptions("hot-replication-demote", GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "local-missing" {
+				return nil, ErrNotFound
+			}
+			return []byte("v-" + key), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(5),
+		WithPeerPicker(picker),
+		WithHotKeyReplication(),
+	)
+	defer group.Close()
+
+	// 不存在的 key 变成热点时不推送负缓存，也没有需要撤销的推送；其他节点负责的 key 在本节点变成热点时不推送
+	for i := 0; i < 10; i++ {
+		group.Get("local-missing")
+		group.mainCache.hotDetector.RecordKey("remote-x", ByteView{b: []byte("remote")})
+	}
+	if !group.mainCache.isHot("local-missing") || !group.mainCache.isHot("remote-x") {
+		t.Fatal("keys should be hot locally")
+	}
+	// 从未推送过的 key 删除时不广播撤销
+	group.Get("local-cold")
+	group.Delete("local-cold")
+	time.Sleep(50 * time.Millisecond)
+	if p, d, _ := replica.pushes(); p != 0 || d != 0 {
+		t.Fatalf("pushes = %d promotes, %d demotes, want none", p, d)
+	}
+	// 非主节点的热点不分配版本
+	group.hotMu.Lock()
+	version := group.hotVersion
+	group.hotMu.Unlock()
+	if version != 0 {
+		t.Fatalf("hot version = %d for keys this node does not own", version)
+	}
+
+	// 推送过的热点删除时撤销
+	for i := 0; i < 10; i++ {
+		group.Get("local-a")
+	}
+	waitFor(t, "promote", func() bool { p, _, _ := replica.pushes(); return p == 1 })
+	group.Delete("local-a")
+	waitFor(t, "delete demote", func() bool { _, d, _ := replica.pushes(); return d == 1 })
+	if _, _, stale := replica.pushes(); stale != 0 {
+		t.Fatalf("%d pushes arrived out of order", stale)
+	}
+}
+
+// test the owner renews leases of its hot keys and counts hits served by peers
+func TestHotKeyReplicationLease(t *testing.T) {
+	owner, replica := newFakePeer(), newFakePeer()
+	replica.hits = 5
+	picker := &fakePicker{owner: owner, replicas: []*fakePeer{replica}, localPrefix: "local-"}
+	group := NewGroupWithOptions("hot-replication-lease", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("v-" + key), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(5),
+		WithPeerPicker(picker),
+		WithHotKeyReplication(),
+		WithHotKeyLease(30*time.Millisecond),
+	)
+	defer group.Close()
+
+	for i := 0; i < 10; i++ {
+		group.Get("local-a")
+	}
+	waitFor(t, "lease renewal", func() bool { p, _, _ := replica.pushes(); return p >= 4 })
+	if _, _, stale := replica.pushes(); stale != 0 {
+		t.Fatalf("%d pushes arrived out of order", stale)
+	}
+	// 其他节点提供的命中计入主节点的频率，热点不会因为流量转移而降级
+	// 命中次数在推送完成后才合并，本地命中还在热点层中，按 rank 统计
+	detector := group.mainCache.hotDetector
+	waitFor(t, "served hits", func() bool {
+		detector.mu.Lock()
+		defer detector.mu.Unlock()
+		return detector.rank("local-a") >= 10+3*replica.hits
+	})
+	if !group.mainCache.isHot("local-a") {
+		t.Fatal("local-a should stay hot")
+	}
+
+	// 过短的租约按下限处理
+	short := NewGroupWithOptions("hot-replication-short-lease", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(key), nil
+		}),
+		WithPeerPicker(picker),
+		WithHotKeyReplication(),
+		WithHotKeyLease(time.Nanosecond),
+	)
+	defer short.Close()
+	if short.hotLease != MinHotKeyLease {
+		t.Fatalf("hot lease = %v, want %v", short.hotLease, MinHotKeyLease)
+	}
+}
+
+// test Set replaces the value of a hot key even when its frequency has decayed
+func TestSetHotKey(t *testing.T) {
+	group := NewGroupWithOptions("set-hot", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("v1"), nil
+		}),
+		WithCacheBytes(1<<20),
+		WithHotKeyThreshold(4),
+	)
+	defer group.Close()
+
+	for i := 0; i < 5; i++ {
+		group.Get("k")
+	}
+	if !group.mainCache.isHot("k") {
+		t.Fatal("k should be hot")
+	}
+	// 热点层的命中不计入频率，衰减后频率低于阈值，但 key 仍在热点层
+	group.mainCache.hotDetector.cms.Decay()
+	if err := group.Set("k", []byte("v2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := group.Get("k"); err != nil || v.String() != "v2" {
+		t.Fatalf("Get after Set = %q, %v", v.String(), err)
+	}
+	group.setCache("k", ByteView{b: []byte("v3")})
+	if v, err := group.Get("k"); err != nil || v.String() != "v3" {
+		t.Fatalf("Get after replica write = %q, %v", v.String(), err)
+	}
+}
@@ -0,0 +1,98 @@
+package distcache
+
+import (
+	"github.com/simplely77/distcache/arc"
+	"github.com/simplely77/distcache/lfu"
+	"github.com/simplely77/distcache/lru"
+	"github.com/simplely77/distcache/sieve"
+)
+
+// EvictionPolicy 是缓存分片使用的淘汰策略
+// 实现需要和 lru.Cache 一样按 len(key)+value.Len() 统计字节数，超过 maxBytes 时自行淘汰并调用 onEvicted；
+// 实现不需要并发安全，由分片加锁保护
+type EvictionPolicy interface {
+	Add(key string, value lru.Value)
+	Get(key string) (lru.Value, bool)
+	// Peek 查找但不影响淘汰顺序
+	Peek(key string) (lru.Value, bool)
+	Remove(key string)
+	// RemoveOldest 按策略淘汰一个条目
+	RemoveOldest()
+	// Victim 返回下一个会被淘汰的键，但不淘汰它
+	Victim() (key string, ok bool)
+	// Range 大致按淘汰顺序（先淘汰的在前）遍历条目，fn 返回 false 时停止
+	Range(fn func(key string, value lru.Value) bool)
+	Len() int
+	NBytes() int64
+}
+
+// PolicyFactory 为每个分片创建一个淘汰策略实例
+type PolicyFactory func(maxBytes int64, onEvicted func(key string, value lru.Value)) EvictionPolicy
+
+var (
+	// LRUPolicy 淘汰最久未使用的条目（默认）
+	LRUPolicy PolicyFactory = func(maxBytes int64, onEvicted func(string, lru.Value)) EvictionPolicy {
+		return lru.New(maxBytes, onEvicted)
+	}
+	// LFUPolicy 淘汰访问频率最低的条目
+	LFUPolicy PolicyFactory = func(maxBytes int64, onEvicted func(string, lru.Value)) EvictionPolicy {
+		return lfu.New(maxBytes, onEvicted)
+	}
+	// ARCPolicy 在最近使用和频繁使用之间自适应，适合混合了扫描的负载
+	ARCPolicy PolicyFactory = func(maxBytes int64, onEvicted func(string, lru.Value)) EvictionPolicy {
+		return arc.New(maxBytes, onEvicted)
+	}
+	// SIEVEPolicy 命中时不移动节点，适合扫描较多、读多写少的负载
+	SIEVEPolicy PolicyFactory = func(maxBytes int64, onEvicted func(string, lru.Value)) EvictionPolicy {
+		return sieve.New(maxBytes, onEvicted)
+	}
+	// SlabPolicy 把条目编码后连续保存在每个分片的一块字节 slab 中，索引只包含整数偏移，
+	// 条目很多时可以显著减少 GC 扫描；淘汰顺序与 SIEVE 类似（二次机会 FIFO），读取时需要拷贝值
+	SlabPolicy PolicyFactory = func(maxBytes int64, onEvicted func(string, lru.Value)) EvictionPolicy {
+		return newSlabPolicy(maxBytes, onEvicted)
+	}
+)
+
+// EvictReason 说明条目离开本地缓存的原因
+type EvictReason int
+
+const (
+	// EvictCapacity 缓存容量不足，被淘汰策略淘汰
+	EvictCapacity EvictReason = iota
+	// EvictExpired 条目已过期
+	EvictExpired
+	// EvictDeleted 条目被 Delete 删除
+	EvictDeleted
+	// EvictReplaced 条目被同一个 key 的新值覆盖
+	EvictReplaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictDeleted:
+		return "deleted"
+	case EvictReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// evictedEntry 是一次淘汰事件
+type evictedEntry struct {
+	key    string
+	value  ByteView
+	reason EvictReason
+	onDisk bool // 磁盘层中仍有同样的值，容量淘汰时不需要再写入
+}
+
+var (
+	_ EvictionPolicy = (*lru.Cache)(nil)
+	_ EvictionPolicy = (*lfu.Cache)(nil)
+	_ EvictionPolicy = (*arc.Cache)(nil)
+	_ EvictionPolicy = (*sieve.Cache)(nil)
+)
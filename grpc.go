@@ -2,6 +2,7 @@ package distcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -17,6 +18,8 @@ import (
 const (
 	defaultGRPCReplicas     = 50
 	defaultReplicaNodeCount = 2
+	// 调用方未设置截止时间时，节点间请求使用的默认超时
+	defaultRPCTimeout = 10 * time.Second
 )
 
 type GRPCPool struct {
@@ -46,11 +49,11 @@ func (p *GRPCPool) Log(format string, v ...interface{}) {
 	}
 }
 
-// Set 处理gRPC Set请求 - 仅用于副本同步，不对外开放
-// 注意：这是内部方法，用于节点间同步副本数据
+// Set 处理gRPC Set请求 - 用于主节点写入和副本同步
+// 注意：这是内部方法，副本由发起写入的节点负责同步，这里只写本地缓存
 func (p *GRPCPool) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
 	start := time.Now()
-	p.Log("grpc Set (replica sync) %s %s", req.Group, req.Key)
+	p.Log("grpc Set %s %s", req.Group, req.Key)
 
 	group := GetGroup(req.Group)
 	if group == nil {
@@ -65,7 +68,21 @@ func (p *GRPCPool) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse
 	}
 
 	// 直接写入本地缓存，不再触发副本同步（避免循环）
-	group.setCache(req.Key, ByteView{b: req.Data})
+	value, err := compressedView(req.Data, Compression(req.Codec), int(req.RawSize))
+	if err != nil {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("grpc_set", "error")
+			GetMetrics().RecordDuration("grpc_set", "error", time.Since(start).Seconds())
+		}
+		return &pb.SetResponse{
+			Success: false,
+			Err:     err.Error(),
+		}, nil
+	}
+	if req.ExpireAt > 0 {
+		value.expire = time.Unix(0, req.ExpireAt)
+	}
+	group.setCache(req.Key, value)
 
 	if IsMetricsEnabled() {
 		GetMetrics().RecordRequest("grpc_set", "success")
@@ -98,7 +115,7 @@ func (p *GRPCPool) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.Delet
 	// 异步删除副本
 	for _, peer := range p.ReplicaPeersForKey(req.Key) {
 		go func(pg PeerClient) {
-			if err := pg.Delete(req.Group, req.Key); err != nil {
+			if err := pg.Delete(context.Background(), req.Group, req.Key); err != nil {
 				p.Log("replica Delete error: %v", err)
 			}
 		}(peer)
@@ -129,7 +146,19 @@ func (p *GRPCPool) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse
 		}, nil
 	}
 
-	view, err := group.Get(req.Key)
+	// 使用请求的 ctx，调用方的截止时间会随 gRPC 传递到本节点的 Getter
+	view, err := group.GetContext(ctx, req.Key)
+	if errors.Is(err, ErrNotFound) {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("grpc_get", "not_found")
+			GetMetrics().RecordDuration("grpc_get", "not_found", time.Since(start).Seconds())
+		}
+		return &pb.GetResponse{
+			Found:    false,
+			NotFound: true,
+			Err:      err.Error(),
+		}, nil
+	}
 	if err != nil {
 		if IsMetricsEnabled() {
 			GetMetrics().RecordRequest("grpc_get", "error")
@@ -146,12 +175,96 @@ func (p *GRPCPool) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse
 		GetMetrics().RecordDuration("grpc_get", "success", time.Since(start).Seconds())
 	}
 
+	// 压缩过的值原样传输，由客户端解压
 	return &pb.GetResponse{
-		Found: true,
-		Data:  view.ByteSlice(),
+		Found:   true,
+		Data:    view.b,
+		Codec:   uint32(view.codec),
+		RawSize: uint64(view.n),
 	}, nil
 }
 
+// BatchGet 处理gRPC批量获取请求
+func (p *GRPCPool) BatchGet(ctx context.Context, req *pb.BatchGetRequest) (*pb.BatchGetResponse, error) {
+	start := time.Now()
+	p.Log("grpc BatchGet %s %d keys", req.Group, len(req.Keys))
+
+	group := GetGroup(req.Group)
+	if group == nil {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("grpc_batch_get", "error")
+			GetMetrics().RecordDuration("grpc_batch_get", "error", time.Since(start).Seconds())
+		}
+		return &pb.BatchGetResponse{
+			Err: "no such group: " + req.Group,
+		}, nil
+	}
+
+	values := group.GetManyContext(ctx, req.Keys)
+	results := make([]*pb.GetResponse, len(req.Keys))
+	for i, key := range req.Keys {
+		r := values[key]
+		switch {
+		case r.Err == nil:
+			results[i] = &pb.GetResponse{Found: true, Data: r.Value.b, Codec: uint32(r.Value.codec), RawSize: uint64(r.Value.n)}
+		case errors.Is(r.Err, ErrNotFound):
+			results[i] = &pb.GetResponse{NotFound: true, Err: r.Err.Error()}
+		default:
+			results[i] = &pb.GetResponse{Err: r.Err.Error()}
+		}
+	}
+
+	if IsMetricsEnabled() {
+		GetMetrics().RecordRequest("grpc_batch_get", "success")
+		GetMetrics().RecordDuration("grpc_batch_get", "success", time.Since(start).Seconds())
+	}
+
+	return &pb.BatchGetResponse{Results: results}, nil
+}
+
+// HotKey 处理主节点推送的热点提升和撤销，只修改本地热点层，不再继续广播
+func (p *GRPCPool) HotKey(ctx context.Context, req *pb.HotKeyRequest) (*pb.HotKeyResponse, error) {
+	start := time.Now()
+	p.Log("grpc HotKey %s %s promote=%v", req.Group, req.Key, req.Promote)
+
+	group := GetGroup(req.Group)
+	if group == nil {
+		if IsMetricsEnabled() {
+			GetMetrics().RecordRequest("grpc_hot_key", "error")
+			GetMetrics().RecordDuration("grpc_hot_key", "error", time.Since(start).Seconds())
+		}
+		return &pb.HotKeyResponse{
+			Success: false,
+			Err:     "no such group: " + req.Group,
+		}, nil
+	}
+
+	var hits uint64
+	lease := time.Duration(req.LeaseMs) * time.Millisecond
+	if req.Promote {
+		value, err := compressedView(req.Data, Compression(req.Codec), int(req.RawSize))
+		if err != nil {
+			return &pb.HotKeyResponse{
+				Success: false,
+				Err:     err.Error(),
+			}, nil
+		}
+		if req.ExpireAt > 0 {
+			value.expire = time.Unix(0, req.ExpireAt)
+		}
+		hits = group.pinHotKey(req.Key, value, req.Version, lease)
+	} else {
+		group.unpinHotKey(req.Key, req.Version, lease)
+	}
+
+	if IsMetricsEnabled() {
+		GetMetrics().RecordRequest("grpc_hot_key", "success")
+		GetMetrics().RecordDuration("grpc_hot_key", "success", time.Since(start).Seconds())
+	}
+
+	return &pb.HotKeyResponse{Success: true, Hits: hits}, nil
+}
+
 // 启动 gRPC 服务器
 func (p *GRPCPool) Serve(addr string) error {
 	lis, err := net.Listen("tcp", addr)
@@ -162,11 +275,14 @@ func (p *GRPCPool) Serve(addr string) error {
 	return p.server.Serve(lis)
 }
 
-// 关闭 gRPC 服务器
+// 关闭 gRPC 服务器，之后为配置了 WithSnapshotPath 的 Group 保存快照
 func (p *GRPCPool) Stop() {
 	if p.server != nil {
 		p.server.GracefulStop()
 	}
+	if err := SaveSnapshots(); err != nil {
+		log.Printf("[Server %s] save snapshots failed: %v", p.self, err)
+	}
 }
 
 func (p *GRPCPool) SetPeers(peers ...string) {
@@ -213,6 +329,19 @@ func (p *GRPCPool) ReplicaPeersForKey(key string) []PeerClient {
 	return peers
 }
 
+// AllPeers 实现 PeerBroadcaster 接口，返回除本节点外的所有节点
+func (p *GRPCPool) AllPeers() []PeerClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peers := make([]PeerClient, 0, len(p.grpcClients))
+	for addr, client := range p.grpcClients {
+		if addr != p.self {
+			peers = append(peers, client)
+		}
+	}
+	return peers
+}
+
 // client字段，用于复用连接，所以需要实现getClient和Close()方法
 type grpcClient struct {
 	addr   string
@@ -222,13 +351,25 @@ type grpcClient struct {
 	mu sync.RWMutex
 }
 
-func (g *grpcClient) Get(group string, key string) ([]byte, error) {
+// Addr 返回远程节点地址
+func (c *grpcClient) Addr() string {
+	return c.addr
+}
+
+// withDefaultTimeout 如果 ctx 没有截止时间，则加上默认超时，防止请求阻塞
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultRPCTimeout)
+}
+
+func (g *grpcClient) Get(ctx context.Context, group string, key string) (ByteView, error) {
 	client, err := g.getClient()
 	if err != nil {
-		return nil, err
+		return ByteView{}, err
 	}
-	// 设置请求的超时时间，防止请求阻塞
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx)
 	defer cancel()
 
 	req := &pb.GetRequest{
@@ -238,30 +379,38 @@ func (g *grpcClient) Get(group string, key string) ([]byte, error) {
 
 	resp, err := client.Get(ctx, req)
 	if err != nil {
-		return nil, err
+		return ByteView{}, err
 	}
 
+	if resp.NotFound {
+		return ByteView{}, ErrNotFound
+	}
 	if !resp.Found {
-		return nil, fmt.Errorf("key not found: %s", resp.Err)
+		return ByteView{}, fmt.Errorf("key not found: %s", resp.Err)
 	}
 
-	return resp.Data, nil
+	return compressedView(resp.Data, Compression(resp.Codec), int(resp.RawSize))
 }
 
 // Set 实现PeerClient接口
-func (g *grpcClient) Set(group string, key string, value []byte) error {
+func (g *grpcClient) Set(ctx context.Context, group string, key string, value ByteView) error {
 	client, err := g.getClient()
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx)
 	defer cancel()
 
 	req := &pb.SetRequest{
-		Group: group,
-		Key:   key,
-		Data:  value,
+		Group:   group,
+		Key:     key,
+		Data:    value.b,
+		Codec:   uint32(value.codec),
+		RawSize: uint64(value.n),
+	}
+	if !value.expire.IsZero() {
+		req.ExpireAt = value.expire.UnixNano()
 	}
 
 	resp, err := client.Set(ctx, req)
@@ -277,13 +426,13 @@ func (g *grpcClient) Set(group string, key string, value []byte) error {
 }
 
 // Delete 实现PeerClient接口
-func (g *grpcClient) Delete(group string, key string) error {
+func (g *grpcClient) Delete(ctx context.Context, group string, key string) error {
 	client, err := g.getClient()
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := withDefaultTimeout(ctx)
 	defer cancel()
 
 	req := &pb.DeleteRequest{
@@ -303,6 +452,93 @@ func (g *grpcClient) Delete(group string, key string) error {
 	return nil
 }
 
+// PromoteHotKey 实现 HotKeyPusher 接口
+func (g *grpcClient) PromoteHotKey(ctx context.Context, group string, key string, value ByteView, version uint64, lease time.Duration) (uint64, error) {
+	req := &pb.HotKeyRequest{
+		Group:   group,
+		Key:     key,
+		Promote: true,
+		Data:    value.b,
+		Codec:   uint32(value.codec),
+		RawSize: uint64(value.n),
+		Version: version,
+		LeaseMs: lease.Milliseconds(),
+	}
+	if !value.expire.IsZero() {
+		req.ExpireAt = value.expire.UnixNano()
+	}
+	return g.pushHotKey(ctx, req)
+}
+
+// DemoteHotKey 实现 HotKeyPusher 接口
+func (g *grpcClient) DemoteHotKey(ctx context.Context, group string, key string, version uint64, lease time.Duration) error {
+	_, err := g.pushHotKey(ctx, &pb.HotKeyRequest{Group: group, Key: key, Version: version, LeaseMs: lease.Milliseconds()})
+	return err
+}
+
+func (g *grpcClient) pushHotKey(ctx context.Context, req *pb.HotKeyRequest) (uint64, error) {
+	client, err := g.getClient()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	resp, err := client.HotKey(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if !resp.Success {
+		return 0, fmt.Errorf("hot key failed: %s", resp.Err)
+	}
+
+	return resp.Hits, nil
+}
+
+// BatchGet 实现PeerClient接口
+func (g *grpcClient) BatchGet(ctx context.Context, group string, keys []string) ([]GetResult, error) {
+	client, err := g.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	req := &pb.BatchGetRequest{
+		Group: group,
+		Keys:  keys,
+	}
+
+	resp, err := client.BatchGet(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("batch get failed: %s", resp.Err)
+	}
+	if len(resp.Results) != len(keys) {
+		return nil, fmt.Errorf("batch get failed: expected %d results, got %d", len(keys), len(resp.Results))
+	}
+
+	results := make([]GetResult, len(keys))
+	for i, r := range resp.Results {
+		switch {
+		case r.Found:
+			value, err := compressedView(r.Data, Compression(r.Codec), int(r.RawSize))
+			results[i] = GetResult{Value: value, Err: err}
+		case r.NotFound:
+			results[i] = GetResult{Err: ErrNotFound}
+		default:
+			results[i] = GetResult{Err: fmt.Errorf("key not found: %s", r.Err)}
+		}
+	}
+	return results, nil
+}
+
 func (g *grpcClient) getClient() (pb.CacheServiceClient, error) {
 	// 双重锁机制，第一次读锁检查是否已经建立连接，如果有则直接返回
 	g.mu.RLock()
@@ -3,6 +3,7 @@ package distcache
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -236,6 +237,200 @@ func TestGRPCPool_Delete(t *testing.T) {
 	})
 }
 
+// 测试数据源返回 ErrNotFound 时响应带有 not_found 标记
+func TestGRPCPool_GetNotFound(t *testing.T) {
+	addr := "127.0.0.1:50055"
+	_, stop := startGRPCServer(t, addr)
+	defer stop()
+
+	NewGroup("missing-scores", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return nil, ErrNotFound
+		}))
+
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := client.Get(ctx, &pb.GetRequest{Group: "missing-scores", Key: "Tom"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Found || !resp.NotFound {
+		t.Fatalf("expected not_found response, got %v", resp)
+	}
+
+	// 其他错误不应带有 not_found 标记
+	resp, err = client.Get(ctx, &pb.GetRequest{Group: "scores", Key: "Unknown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NotFound {
+		t.Fatal("expected plain error without not_found flag")
+	}
+}
+
+// 测试批量获取
+func TestGRPCPool_BatchGet(t *testing.T) {
+	addr := "127.0.0.1:50056"
+	_, stop := startGRPCServer(t, addr)
+	defer stop()
+
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	keys := []string{"Tom", "Unknown", "Sam"}
+	resp, err := client.BatchGet(ctx, &pb.BatchGetRequest{Group: "scores", Keys: keys})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Results) != len(keys) {
+		t.Fatalf("expected %d results, got %d", len(keys), len(resp.Results))
+	}
+	if !resp.Results[0].Found || string(resp.Results[0].Data) != "630" {
+		t.Errorf("Tom: expected 630, got %v", resp.Results[0])
+	}
+	if resp.Results[1].Found {
+		t.Errorf("Unknown: expected not found")
+	}
+	if !resp.Results[2].Found || string(resp.Results[2].Data) != "567" {
+		t.Errorf("Sam: expected 567, got %v", resp.Results[2])
+	}
+
+	resp, err = client.BatchGet(ctx, &pb.BatchGetRequest{Group: "notExist", Keys: keys})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Err == "" {
+		t.Fatal("expected error message for missing group")
+	}
+}
+
+// 测试副本写入携带过期时间
+func TestGRPCPool_SetWithExpire(t *testing.T) {
+	addr := "127.0.0.1:50054"
+	_, stop := startGRPCServer(t, addr)
+	defer stop()
+
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := client.Set(ctx, &pb.SetRequest{
+		Group:    "scores",
+		Key:      "Exp",
+		Data:     []byte("1"),
+		ExpireAt: time.Now().Add(50 * time.Millisecond).UnixNano(),
+	})
+	if err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	resp, err := client.Get(ctx, &pb.GetRequest{Group: "scores", Key: "Exp"})
+	if err != nil || !resp.Found || string(resp.Data) != "1" {
+		t.Fatalf("expected cached replica before expiration, got %v %v", resp, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	resp, err = client.Get(ctx, &pb.GetRequest{Group: "scores", Key: "Exp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Found {
+		t.Fatal("expected replica to expire")
+	}
+}
+
+// 测试压缩的值在节点间按压缩形式传输
+func TestGRPCPool_Compression(t *testing.T) {
+	addr := "127.0.0.1:50057"
+	_, stop := startGRPCServer(t, addr)
+	defer stop()
+
+	large := strings.Repeat("compressible ", 200)
+	group := NewGroupWithOptions("compressed-scores", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(large), nil
+		}), WithCacheBytes(1<<20), WithCompression(CompressionGzip, 256))
+	defer group.Close()
+
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := client.Get(ctx, &pb.GetRequest{Group: "compressed-scores", Key: "k"})
+	if err != nil || !resp.Found {
+		t.Fatalf("unexpected response: %v %v", resp, err)
+	}
+	if Compression(resp.Codec) != CompressionGzip || len(resp.Data) >= len(large) {
+		t.Fatalf("expected gzip data smaller than %d, got codec %d len %d", len(large), resp.Codec, len(resp.Data))
+	}
+
+	// 本节点不会被选为远程节点，直接创建客户端
+	peer := &grpcClient{addr: addr}
+	defer peer.Close()
+	// 远程节点返回的值保持压缩，读取时才解压
+	data, err := peer.Get(ctx, "compressed-scores", "k")
+	if err != nil || data.codec != CompressionGzip || data.Len() != len(large) || data.String() != large {
+		t.Fatalf("PeerClient.Get returned codec %v len %d, %v", data.codec, data.Len(), err)
+	}
+
+	// 副本写入保留压缩方式
+	value := group.mainCache.compressor.compress(ByteView{b: []byte(large)})
+	if err := peer.Set(ctx, "compressed-scores", "replica", value); err != nil {
+		t.Fatalf("PeerClient.Set failed: %v", err)
+	}
+	if v, ok := group.Peek("replica"); !ok || v.codec != CompressionGzip || v.String() != large {
+		t.Fatalf("replica not stored compressed: ok=%v codec=%v", ok, v.codec)
+	}
+}
+
+// 测试主节点推送的热点在本节点直接提供
+func TestGRPCPool_HotKey(t *testing.T) {
+	addr := "127.0.0.1:50058"
+	_, stop := startGRPCServer(t, addr)
+	defer stop()
+
+	group := NewGroupWithOptions("hot-pushed", GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte("origin"), nil
+		}), WithCacheBytes(1<<20))
+	defer group.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	peer := &grpcClient{addr: addr}
+	defer peer.Close()
+	value := ByteView{b: []byte("pushed"), expire: time.Now().Add(time.Minute)}
+	if _, err := peer.PromoteHotKey(ctx, "hot-pushed", "k", value, 1, time.Minute); err != nil {
+		t.Fatalf("PromoteHotKey failed: %v", err)
+	}
+	if v, err := group.Get("k"); err != nil || v.String() != "pushed" || !v.Expire().Equal(value.expire) {
+		t.Fatalf("pushed hot key = %q, %v", v.String(), err)
+	}
+
+	if err := peer.DemoteHotKey(ctx, "hot-pushed", "k", 2, time.Minute); err != nil {
+		t.Fatalf("DemoteHotKey failed: %v", err)
+	}
+	if group.mainCache.isHot("k") {
+		t.Fatal("expected hot key to be demoted")
+	}
+
+	if err := peer.DemoteHotKey(ctx, "no-such-group", "k", 1, time.Minute); err == nil {
+		t.Fatal("expected error for unknown group")
+	}
+}
+
 // 测试多节点场景
 func TestGRPCPool_MultiNodes(t *testing.T) {
 	// 创建三个节点
@@ -374,7 +569,7 @@ func TestGRPCClient_Interface(t *testing.T) {
 		// 测试 PeerClient 接口的方法
 
 		// 测试 Set
-		err := peerClient.Set("scores", "test-peer-key", []byte("test-value"))
+		err := peerClient.Set(context.Background(), "scores", "test-peer-key", ByteView{b: []byte("test-value")})
 		if err != nil {
 			t.Fatalf("PeerClient.Set failed: %v", err)
 		}
@@ -383,22 +578,22 @@ func TestGRPCClient_Interface(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 
 		// 测试 Get
-		data, err := peerClient.Get("scores", "test-peer-key")
+		data, err := peerClient.Get(context.Background(), "scores", "test-peer-key")
 		if err != nil {
 			t.Fatalf("PeerClient.Get failed: %v", err)
 		}
-		if string(data) != "test-value" {
-			t.Errorf("expected 'test-value', got %s", string(data))
+		if data.String() != "test-value" {
+			t.Errorf("expected 'test-value', got %s", data.String())
 		}
 
 		// 测试 Delete
-		err = peerClient.Delete("scores", "test-peer-key")
+		err = peerClient.Delete(context.Background(), "scores", "test-peer-key")
 		if err != nil {
 			t.Fatalf("PeerClient.Delete failed: %v", err)
 		}
 
 		// 验证删除成功
-		_, err = peerClient.Get("scores", "test-peer-key")
+		_, err = peerClient.Get(context.Background(), "scores", "test-peer-key")
 		if err == nil {
 			t.Error("expected error after delete, got nil")
 		}
@@ -0,0 +1,39 @@
+package distcache
+
+import "hash/maphash"
+
+// HashFunc 把 key 映射到本地缓存分片，需要对同一个 key 返回相同的值且分布均匀
+type HashFunc func(key string) uint32
+
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// FNV1aHash 是默认的分片哈希，直接在字符串上计算 FNV-1a，不分配内存
+func FNV1aHash(key string) uint32 {
+	h := uint32(fnvOffset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= fnvPrime32
+	}
+	return h
+}
+
+// maphashSeed 在进程内固定，保证同一个 key 总是落在同一个分片
+var maphashSeed = maphash.MakeSeed()
+
+// MaphashHash 使用运行时的 maphash，长 key 时比 FNV-1a 更快，但结果只在当前进程内稳定
+func MaphashHash(key string) uint32 {
+	h := maphash.String(maphashSeed, key)
+	return uint32(h ^ h>>32)
+}
+
+// nextPowerOfTwo 返回不小于 n 的最小 2 的幂
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
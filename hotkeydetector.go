@@ -1,24 +1,95 @@
 package distcache
 
 import (
+	"bytes"
+	"container/heap"
 	"sync"
+	"sync/atomic"
 	"time"
 	"github.com/simplely77/distcache/countminsketch"
+	"github.com/simplely77/distcache/topk"
 )
 
+const (
+	// DefaultHotKeyMaxKeys 热点层默认最多保存的 key 数
+	DefaultHotKeyMaxKeys = 1024
+	// DefaultHotKeyBytesRatio 热点层默认最多占用 cacheBytes 的 1/DefaultHotKeyBytesRatio
+	DefaultHotKeyBytesRatio = 8
+	// DefaultTopKSize top-K 统计每个分段跟踪的 key 数
+	DefaultTopKSize = 100
+)
+
+// hotEntry 是热点层中的条目
+// 热点层的命中不经过 RecordKey，命中次数先累加到 hits，衰减、淘汰或查询 top-K 时再合并到 Count-Min Sketch 和 top-K，
+// 避免在读路径上加锁
+type hotEntry struct {
+	value ByteView
+	hits  atomic.Uint64
+	// remote 表示条目由主节点推送而来，本地访问频率不足时不会按频率降级
+	remote bool
+	// 以下字段只对推送的条目有效：推送的版本、租约到期时间（零值表示不限制，到期后即使仍有命中也会删除）
+	// 和上次推送以来的命中次数（续约时报告给主节点）
+	version uint64
+	lease   time.Time
+	served  atomic.Uint64
+}
+
+// hotPin 是主节点推送的版本和租约
+type hotPin struct {
+	version uint64
+	lease   time.Time
+}
+
+// unpinMark 记录被撤销的推送，until 之前忽略版本不比它新的提升
+type unpinMark struct {
+	version uint64
+	until   time.Time
+}
+
 type HotKeyDetector struct {
 	cms       *countminsketch.CountMinSketch
-	hotKeys   sync.Map // key -> ByteView
+	hotKeys   sync.Map // key -> *hotEntry，读取不加锁
+	topK      *topk.Tracker
 	threshold uint64
 	decayIntv time.Duration
-	stopCh    chan struct{} // 用于停止定期衰减
+	// 热点层的容量上限，0 表示不限制
+	maxKeys  int
+	maxBytes int64
+	// mu 保护热点的写入、淘汰和下面的计数
+	mu     sync.Mutex
+	count  int
+	nbytes int64
+	// 按频率排列的最小堆，用于找出最冷的热点，受 mu 保护
+	ranks hotRanks
+	byKey map[string]*hotRank
+	// 热点层已满时最冷热点的频率下界，不比它更热的 key 不可能晋升，为 0 表示未满
+	floor atomic.Uint64
+	// 最近被撤销的推送，受 mu 保护
+	unpinned map[string]unpinMark
+	// 热点层大小变化时调用，可以为 nil
+	onResize func(keys int, bytes int64)
+	// 本地热点提升、更新或降级时调用，受 mu 保护，可以为 nil
+	onChange func(key string, value ByteView, hot bool)
+	stopCh   chan struct{} // 用于停止定期衰减
+	stopOnce sync.Once
 }
 
+// NewHotKeyDetector 创建热点检测器，热点层最多保存 DefaultHotKeyMaxKeys 个 key，不限制字节数
 func NewHotKeyDetector(threshold uint64, decayInterval time.Duration) *HotKeyDetector {
+	return newHotKeyDetector(threshold, decayInterval, DefaultHotKeyMaxKeys, 0, nil)
+}
+
+func newHotKeyDetector(threshold uint64, decayInterval time.Duration, maxKeys int, maxBytes int64, onResize func(int, int64)) *HotKeyDetector {
 	h := &HotKeyDetector{
 		cms:       countminsketch.NewCountMinSketch(0.001, 0.99),
+		topK:      topk.New(DefaultTopKSize),
 		threshold: threshold,
 		decayIntv: decayInterval,
+		maxKeys:   maxKeys,
+		maxBytes:  maxBytes,
+		onResize:  onResize,
+		byKey:     make(map[string]*hotRank),
+		unpinned:  make(map[string]unpinMark),
 		stopCh:    make(chan struct{}),
 	}
 	go h.periodicDecay()
@@ -28,25 +99,402 @@ func NewHotKeyDetector(threshold uint64, decayInterval time.Duration) *HotKeyDet
 // RecordKey 在访问时调用
 func (h *HotKeyDetector) RecordKey(key string, value ByteView) {
 	h.cms.Add(key, 1)
+	h.topK.Add(key, 1)
 	count := h.cms.Count(key)
 	if count >= h.threshold {
-		// 检查是否是新晋升的热点key
-		if _, exists := h.hotKeys.Load(key); !exists {
-			if IsMetricsEnabled() {
-				GetMetrics().RecordHotKey("promoted")
-			}
+		if _, hot := h.hotKeys.Load(key); !hot && count <= h.floor.Load() {
+			// 热点层已满且不比最冷的热点更热，不用加锁
+			return
+		}
+		h.promote(key, value, count, nil)
+	}
+}
+
+// update 用新值替换已有的热点，key 不是热点时什么也不做
+// 热点层的命中延迟合并到 Count-Min Sketch，写入时不能依赖频率判断 key 是否仍是热点
+func (h *HotKeyDetector) update(key string, value ByteView) {
+	if _, ok := h.hotKeys.Load(key); ok {
+		h.promote(key, value, 0, nil)
+	}
+}
+
+// pin 把主节点推送的热点写入热点层，容量规则与本地晋升相同，不会触发 onChange
+// 版本不比已收到的新的推送被忽略，lease 小于等于 0 表示不限制租约；返回上次推送以来该热点的命中次数
+func (h *HotKeyDetector) pin(key string, value ByteView, version uint64, lease time.Duration) uint64 {
+	var served uint64
+	if v, ok := h.hotKeys.Load(key); ok && v.(*hotEntry).remote {
+		served = v.(*hotEntry).served.Swap(0)
+	}
+	p := &hotPin{version: version}
+	if lease > 0 {
+		p.lease = time.Now().Add(lease)
+	}
+	h.promote(key, value, h.threshold, p)
+	return served
+}
+
+// unpin 撤销推送的热点，已经收到版本更新的提升时忽略；之后 keep 时间内忽略版本不比它新的提升
+func (h *HotKeyDetector) unpin(key string, version uint64, keep time.Duration) bool {
+	h.mu.Lock()
+	if v, ok := h.hotKeys.Load(key); ok && v.(*hotEntry).remote && v.(*hotEntry).version > version {
+		h.mu.Unlock()
+		return false
+	}
+	if keep > 0 {
+		h.unpinned[key] = unpinMark{version: version, until: time.Now().Add(keep)}
+	}
+	ok := h.deleteLocked(key)
+	h.mu.Unlock()
+	if ok {
+		h.resized()
+	}
+	return ok
+}
+
+// stalePinLocked 判断推送是否比已收到的提升或撤销更旧，需持有 mu
+func (h *HotKeyDetector) stalePinLocked(key string, old interface{}, exists bool, p *hotPin) bool {
+	if exists && old.(*hotEntry).remote && old.(*hotEntry).version >= p.version {
+		return true
+	}
+	if m, ok := h.unpinned[key]; ok {
+		if p.version <= m.version && time.Now().Before(m.until) {
+			return true
+		}
+		delete(h.unpinned, key)
+	}
+	return false
+}
+
+// promote 写入热点层，已是热点时只更新值；pin 不为 nil 表示主节点推送的热点
+// 热点层已满时淘汰频率最低的热点，新 key 比所有热点都冷时不晋升
+func (h *HotKeyDetector) promote(key string, value ByteView, count uint64, pin *hotPin) {
+	var demoted []string
+	remote := pin != nil
+	h.mu.Lock()
+	onChange := h.onChange
+	old, exists := h.hotKeys.Load(key)
+	if !exists && count < h.threshold {
+		// update 检查之后热点可能已被删除，未达到阈值的新 key 不晋升
+		h.mu.Unlock()
+		return
+	}
+	if pin != nil && h.stalePinLocked(key, old, exists, pin) {
+		h.mu.Unlock()
+		return
+	}
+	size := int64(len(key) + value.size())
+	if h.maxBytes > 0 && size > h.maxBytes {
+		// 单个值超过容量上限，不再作为热点
+		if exists && h.deleteLocked(key) && !old.(*hotEntry).remote {
+			demoted = append(demoted, key)
+		}
+		h.updateFloorLocked()
+		h.mu.Unlock()
+		h.changed(onChange, demoted)
+		h.resized()
+		return
+	}
+	delta := size
+	changed := !exists
+	if exists {
+		remote = remote || old.(*hotEntry).remote
+		// 并发访问可能重复晋升同一个热点，值没有变化时不再通知
+		prev := old.(*hotEntry).value
+		changed = !prev.expire.Equal(value.expire) || prev.codec != value.codec || !bytes.Equal(prev.b, value.b)
+		delta -= int64(len(key) + old.(*hotEntry).value.size())
+	}
+	for h.full(exists, delta) {
+		coldest, rank, ok := h.coldest(key)
+		if !ok || (!exists && rank >= count) {
+			h.updateFloorLocked()
+			h.mu.Unlock()
+			h.changed(onChange, demoted)
+			h.resized()
+			return
+		}
+		if v, _ := h.hotKeys.Load(coldest); !v.(*hotEntry).remote {
+			demoted = append(demoted, coldest)
+		}
+		h.deleteLocked(coldest)
+		if IsMetricsEnabled() {
+			GetMetrics().RecordHotKey("evicted")
+		}
+	}
+	if exists {
+		// 新条目的 hits 从 0 开始，先合并旧条目未统计的命中
+		h.flushHits(key, old.(*hotEntry))
+	}
+	entry := &hotEntry{value: value, remote: remote}
+	switch {
+	case pin != nil:
+		entry.version, entry.lease = pin.version, pin.lease
+	case exists && old.(*hotEntry).remote:
+		// 本地写入推送的热点时保留推送的版本和租约
+		entry.version, entry.lease = old.(*hotEntry).version, old.(*hotEntry).lease
+	}
+	if exists {
+		entry.served.Store(old.(*hotEntry).served.Load())
+	}
+	h.hotKeys.Store(key, entry)
+	h.nbytes += delta
+	if !exists {
+		h.count++
+		r := &hotRank{key: key, rank: h.rank(key)}
+		heap.Push(&h.ranks, r)
+		h.byKey[key] = r
+	}
+	h.updateFloorLocked()
+	h.mu.Unlock()
+	if !exists && IsMetricsEnabled() {
+		GetMetrics().RecordHotKey("promoted")
+	}
+	h.changed(onChange, demoted)
+	if changed && !remote && onChange != nil {
+		onChange(key, value, true)
+	}
+	h.resized()
+}
+
+// setMaxBytes 调整热点层的字节上限，缩小时淘汰频率最低的热点
+func (h *HotKeyDetector) setMaxBytes(n int64) {
+	var demoted []string
+	h.mu.Lock()
+	h.maxBytes = n
+	onChange := h.onChange
+	for n > 0 && h.nbytes > n {
+		coldest, _, ok := h.coldest("")
+		if !ok {
+			break
+		}
+		if v, _ := h.hotKeys.Load(coldest); !v.(*hotEntry).remote {
+			demoted = append(demoted, coldest)
 		}
-		h.hotKeys.Store(key, value)
+		h.deleteLocked(coldest)
+		if IsMetricsEnabled() {
+			GetMetrics().RecordHotKey("evicted")
+		}
+	}
+	h.updateFloorLocked()
+	h.mu.Unlock()
+	h.changed(onChange, demoted)
+	h.resized()
+}
+
+// setOnChange 设置本地热点变化的回调，回调可能在持有分片锁时调用，不能阻塞
+func (h *HotKeyDetector) setOnChange(fn func(key string, value ByteView, hot bool)) {
+	h.mu.Lock()
+	h.onChange = fn
+	h.mu.Unlock()
+}
+
+// changed 通知被降级的本地热点
+func (h *HotKeyDetector) changed(onChange func(string, ByteView, bool), demoted []string) {
+	if onChange == nil {
+		return
+	}
+	for _, key := range demoted {
+		onChange(key, ByteView{}, false)
+	}
+}
+
+// full 判断再写入 delta 字节（以及新 key 时多一个 key）是否会超出容量，需持有 mu
+func (h *HotKeyDetector) full(exists bool, delta int64) bool {
+	if h.maxKeys > 0 && !exists && h.count >= h.maxKeys {
+		return true
 	}
+	return h.maxBytes > 0 && h.nbytes+delta > h.maxBytes
 }
 
-// 获取热点key
+// coldest 返回除 except 外频率最低的热点及其频率，需持有 mu
+// 堆中的频率是上次计算的值，两次衰减之间频率只增不减，堆顶过时时重新计算后下沉，直到堆顶是准确的
+func (h *HotKeyDetector) coldest(except string) (string, uint64, bool) {
+	if r, ok := h.byKey[except]; ok {
+		heap.Remove(&h.ranks, r.index)
+		defer heap.Push(&h.ranks, r)
+	}
+	for len(h.ranks) > 0 {
+		top := h.ranks[0]
+		if rank := h.rank(top.key); rank > top.rank {
+			top.rank = rank
+			heap.Fix(&h.ranks, 0)
+			continue
+		}
+		return top.key, top.rank, true
+	}
+	return "", 0, false
+}
+
+// rank 返回热点的访问频率，包括热点层中尚未合并的命中
+func (h *HotKeyDetector) rank(key string) uint64 {
+	n := h.cms.Count(key)
+	if v, ok := h.hotKeys.Load(key); ok {
+		n += v.(*hotEntry).hits.Load()
+	}
+	return n
+}
+
+// updateFloorLocked 热点层已满时把最冷热点的频率下界记到 floor，需持有 mu
+func (h *HotKeyDetector) updateFloorLocked() {
+	var floor uint64
+	if len(h.ranks) > 0 && ((h.maxKeys > 0 && h.count >= h.maxKeys) || (h.maxBytes > 0 && h.nbytes >= h.maxBytes)) {
+		floor = h.ranks[0].rank
+	}
+	h.floor.Store(floor)
+}
+
+// decay 将频率减半，堆中的频率随之失效，重新计算后重建堆
+func (h *HotKeyDetector) decay() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cms.Decay()
+	for _, r := range h.ranks {
+		r.rank = h.rank(r.key)
+	}
+	heap.Init(&h.ranks)
+	h.updateFloorLocked()
+}
+
+// deleteLocked 删除热点并更新计数，需持有 mu
+func (h *HotKeyDetector) deleteLocked(key string) bool {
+	v, ok := h.hotKeys.LoadAndDelete(key)
+	if !ok {
+		return false
+	}
+	h.flushHits(key, v.(*hotEntry))
+	h.count--
+	h.nbytes -= int64(len(key) + v.(*hotEntry).value.size())
+	if r, ok := h.byKey[key]; ok {
+		heap.Remove(&h.ranks, r.index)
+		delete(h.byKey, key)
+	}
+	h.updateFloorLocked()
+	return true
+}
+
+// remove 从热点层删除 key
+func (h *HotKeyDetector) remove(key string) bool {
+	if _, ok := h.hotKeys.Load(key); !ok {
+		return false
+	}
+	h.mu.Lock()
+	ok := h.deleteLocked(key)
+	h.mu.Unlock()
+	if ok {
+		h.resized()
+	}
+	return ok
+}
+
+// clear 清空热点层
+func (h *HotKeyDetector) clear() {
+	h.mu.Lock()
+	h.hotKeys.Range(func(k, _ interface{}) bool {
+		h.hotKeys.Delete(k)
+		return true
+	})
+	h.count, h.nbytes = 0, 0
+	h.ranks, h.byKey = nil, make(map[string]*hotRank)
+	h.unpinned = make(map[string]unpinMark)
+	h.floor.Store(0)
+	h.mu.Unlock()
+	h.topK.Reset()
+	h.resized()
+}
+
+// Len 返回热点层中的 key 数
+func (h *HotKeyDetector) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Bytes 返回热点层占用的字节数，按 len(key)+value 大小统计
+func (h *HotKeyDetector) Bytes() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.nbytes
+}
+
+func (h *HotKeyDetector) resized() {
+	if h.onResize == nil {
+		return
+	}
+	h.mu.Lock()
+	count, nbytes := h.count, h.nbytes
+	h.mu.Unlock()
+	h.onResize(count, nbytes)
+}
+
+// 获取热点key，已过期的热点会被移除
 func (h *HotKeyDetector) GetHot(key string) (ByteView, bool) {
 	v, ok := h.hotKeys.Load(key)
 	if !ok {
 		return ByteView{}, false
 	}
-	return v.(ByteView), true
+	e := v.(*hotEntry)
+	if now := time.Now(); e.value.expired(now) || e.leaseExpired(now) {
+		h.remove(key)
+		return ByteView{}, false
+	}
+	e.hits.Add(1)
+	if e.remote {
+		e.served.Add(1)
+	}
+	return e.value, true
+}
+
+// PeekHot 返回热点层中的值，不计入命中，也不检查是否过期
+func (h *HotKeyDetector) PeekHot(key string) (ByteView, bool) {
+	v, ok := h.hotKeys.Load(key)
+	if !ok {
+		return ByteView{}, false
+	}
+	return v.(*hotEntry).value, true
+}
+
+// leaseExpired 判断推送的热点租约是否已到期
+func (e *hotEntry) leaseExpired(now time.Time) bool {
+	return !e.lease.IsZero() && now.After(e.lease)
+}
+
+// addHits 把其他节点报告的命中次数计入频率统计
+func (h *HotKeyDetector) addHits(key string, n uint64) {
+	h.cms.Add(key, n)
+	h.topK.Add(key, n)
+}
+
+// rangeLocal 遍历本地晋升的热点（不包括推送来的）
+func (h *HotKeyDetector) rangeLocal(fn func(key string, value ByteView)) {
+	h.hotKeys.Range(func(k, v interface{}) bool {
+		if e := v.(*hotEntry); !e.remote {
+			fn(k.(string), e.value)
+		}
+		return true
+	})
+}
+
+// flushHits 把热点层的命中次数合并到 Count-Min Sketch 和 top-K 统计，返回合并的次数
+// 先加到 sketch 再从 hits 中减去，合并期间 rank 只会偏大，不会低于堆中记录的下界
+// 需持有 mu：两次合并同时读到相同的 hits 会重复计入，并让 hits 减成负数回绕
+func (h *HotKeyDetector) flushHits(key string, e *hotEntry) uint64 {
+	n := e.hits.Load()
+	if n > 0 {
+		h.cms.Add(key, n)
+		h.topK.Add(key, n)
+		e.hits.Add(^(n - 1))
+	}
+	return n
+}
+
+// TopKeys 返回访问次数最多的 n 个 key 及其估计次数，次数随衰减周期减半
+func (h *HotKeyDetector) TopKeys(n int) []topk.Item {
+	h.mu.Lock()
+	h.hotKeys.Range(func(k, v interface{}) bool {
+		h.flushHits(k.(string), v.(*hotEntry))
+		return true
+	})
+	h.mu.Unlock()
+	return h.topK.Top(n)
 }
 
 // 定期衰减频率
@@ -56,25 +504,84 @@ func (h *HotKeyDetector) periodicDecay() {
 	for {
 		select {
 		case <-ticker.C:
-			h.cms.Decay()
-			// 可选：检查热点key，如果访问下降，删除
-			h.hotKeys.Range(func(k, _ interface{}) bool {
-				key := k.(string)
-				if h.cms.Count(key) < h.threshold/2 {
-					h.hotKeys.Delete(key)
-					if IsMetricsEnabled() {
+			h.decay()
+			// 检查热点key，如果访问下降，删除
+			// 本地晋升的热点按频率判断；主节点推送的热点在一个周期内本地没有命中时删除
+			now := time.Now()
+			var demoted []string
+			h.hotKeys.Range(func(k, v interface{}) bool {
+				key, e := k.(string), v.(*hotEntry)
+				h.mu.Lock()
+				hits := h.flushHits(key, e)
+				h.mu.Unlock()
+				switch {
+				case e.value.expired(now), e.leaseExpired(now):
+					h.remove(key)
+				case e.remote:
+					if hits == 0 && h.remove(key) && IsMetricsEnabled() {
 						GetMetrics().RecordHotKey("demoted")
 					}
+				case h.cms.Count(key) < h.threshold/2:
+					if h.remove(key) {
+						demoted = append(demoted, key)
+						if IsMetricsEnabled() {
+							GetMetrics().RecordHotKey("demoted")
+						}
+					}
 				}
 				return true
 			})
+			h.topK.Decay()
+			h.mu.Lock()
+			onChange := h.onChange
+			for key, m := range h.unpinned {
+				if now.After(m.until) {
+					delete(h.unpinned, key)
+				}
+			}
+			h.mu.Unlock()
+			h.changed(onChange, demoted)
 		case <-h.stopCh:
 			return
 		}
 	}
 }
 
-// Stop 停止热点检测器
+// Stop 停止热点检测器，可以重复调用
 func (h *HotKeyDetector) Stop() {
-	close(h.stopCh)
+	h.stopOnce.Do(func() {
+		close(h.stopCh)
+	})
+}
+
+// hotRank 是热点在最小堆中的条目
+type hotRank struct {
+	key   string
+	rank  uint64 // 上次计算的频率，是当前频率的下界
+	index int
+}
+
+// hotRanks 实现 heap.Interface，按 rank 从小到大排列
+type hotRanks []*hotRank
+
+func (r hotRanks) Len() int           { return len(r) }
+func (r hotRanks) Less(i, j int) bool { return r[i].rank < r[j].rank }
+func (r hotRanks) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+	r[i].index = i
+	r[j].index = j
+}
+
+func (r *hotRanks) Push(x interface{}) {
+	item := x.(*hotRank)
+	item.index = len(*r)
+	*r = append(*r, item)
+}
+
+func (r *hotRanks) Pop() interface{} {
+	old := *r
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*r = old[:len(old)-1]
+	return item
 }
@@ -2,7 +2,9 @@ package distcache
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -283,3 +285,259 @@ func BenchmarkHotKeyDetector_GetHot(b *testing.B) {
 		detector.GetHot(key)
 	}
 }
+
+// 过期热点测试
+func TestHotKeyDetector_ExpiredHotKey(t *testing.T) {
+	detector := NewHotKeyDetector(1, time.Minute)
+	defer detector.Stop()
+
+	key := "expire_key"
+	value := ByteView{b: []byte("expire_value"), expire: time.Now().Add(20 * time.Millisecond)}
+	detector.RecordKey(key, value)
+	if _, exists := detector.GetHot(key); !exists {
+		t.Fatal("Key should be hot before expiration")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, exists := detector.GetHot(key); exists {
+		t.Error("Expired key should not be served as hot")
+	}
+	if _, exists := detector.hotKeys.Load(key); exists {
+		t.Error("Expired key should be purged from hot keys")
+	}
+}
+
+// 热点层容量测试
+func TestHotKeyDetector_Limit(t *testing.T) {
+	var keys int
+	var bytes int64
+	detector := newHotKeyDetector(1, time.Minute, 2, 20, func(k int, b int64) {
+		keys, bytes = k, b
+	})
+	defer detector.Stop()
+
+	record := func(key string, value string, times int) {
+		for i := 0; i < times; i++ {
+			detector.RecordKey(key, makeByteView(value))
+		}
+	}
+	record("a", "1", 3)
+	record("b", "1", 5)
+	if detector.Len() != 2 || detector.Bytes() != 4 || keys != 2 || bytes != 4 {
+		t.Fatalf("Len = %d, Bytes = %d, reported %d/%d", detector.Len(), detector.Bytes(), keys, bytes)
+	}
+
+	// 热点层已满，比所有热点都冷的 key 不晋升
+	record("c", "1", 1)
+	if _, ok := detector.GetHot("c"); ok {
+		t.Fatal("colder key should not be promoted")
+	}
+
+	// 更热的 key 淘汰频率最低的热点
+	record("d", "1", 10)
+	if _, ok := detector.GetHot("a"); ok {
+		t.Fatal("coldest hot key should be evicted")
+	}
+	if _, ok := detector.GetHot("d"); !ok {
+		t.Fatal("hotter key should be promoted")
+	}
+
+	// 更新已有热点时按新值统计字节数，超出字节上限时淘汰其他热点
+	record("d", "0123456789abcdefgh", 1)
+	if _, ok := detector.GetHot("b"); ok || detector.Len() != 1 || detector.Bytes() != 19 {
+		t.Fatalf("Len = %d, Bytes = %d after growing d", detector.Len(), detector.Bytes())
+	}
+	// 单个值超过字节上限时不进入热点层
+	record("d", "0123456789abcdefghij", 1)
+	if _, ok := detector.GetHot("d"); ok || detector.Len() != 0 || detector.Bytes() != 0 {
+		t.Fatalf("oversized value should be removed, Len = %d, Bytes = %d", detector.Len(), detector.Bytes())
+	}
+	if keys != 0 || bytes != 0 {
+		t.Fatalf("reported %d/%d, want 0/0", keys, bytes)
+	}
+}
+
+// 淘汰热点时计入热点层的命中，热点层满时不比最冷热点更热的 key 不加锁直接跳过
+func TestHotKeyDetector_ColdestCountsHotHits(t *testing.T) {
+	detector := newHotKeyDetector(2, time.Minute, 2, 0, nil)
+	defer detector.Stop()
+
+	record := func(key string, times int) {
+		for i := 0; i < times; i++ {
+			detector.RecordKey(key, makeByteView("v"))
+		}
+	}
+	record("a", 2)
+	record("b", 3)
+	// a 之后只从热点层读取，命中不经过 RecordKey
+	for i := 0; i < 10; i++ {
+		detector.GetHot("a")
+	}
+	if detector.floor.Load() == 0 {
+		t.Fatal("floor should be set once the hot tier is full")
+	}
+
+	record("c", 3)
+	if _, ok := detector.PeekHot("c"); ok {
+		t.Fatal("key no hotter than the coldest hot key should not be promoted")
+	}
+	record("c", 1)
+	if _, ok := detector.PeekHot("a"); !ok {
+		t.Fatal("key served from the hot tier should not be evicted as the coldest")
+	}
+	if _, ok := detector.PeekHot("b"); ok {
+		t.Fatal("b should be evicted")
+	}
+	if _, ok := detector.PeekHot("c"); !ok {
+		t.Fatal("c should be promoted")
+	}
+}
+
+// 推送热点测试
+func TestHotKeyDetector_Pin(t *testing.T) {
+	var mu sync.Mutex
+	changes := make([]string, 0)
+	detector := NewHotKeyDetector(2, 50*time.Millisecond)
+	defer detector.Stop()
+	detector.setOnChange(func(key string, value ByteView, hot bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, fmt.Sprintf("%s=%v", key, hot))
+	})
+
+	// 本地晋升通知一次，值不变时不重复通知
+	for i := 0; i < 5; i++ {
+		detector.RecordKey("local", makeByteView("v"))
+	}
+	// 推送的热点不通知，本地访问也不会让它变成本地热点
+	detector.pin("remote", makeByteView("v"), 1, 0)
+	for i := 0; i < 5; i++ {
+		detector.RecordKey("remote", makeByteView("v"))
+	}
+	if _, ok := detector.GetHot("remote"); !ok {
+		t.Fatal("pinned key should be hot")
+	}
+
+	// 没有访问后本地热点降级并通知，推送的热点被删除
+	time.Sleep(300 * time.Millisecond)
+	if detector.Len() != 0 {
+		t.Fatalf("Len = %d after decay, want 0", detector.Len())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if expect := []string{"local=true", "local=false"}; !reflect.DeepEqual(changes, expect) {
+		t.Fatalf("changes = %v, want %v", changes, expect)
+	}
+}
+
+// 推送按版本生效：更旧的提升和撤销被忽略，撤销后租约内更旧的提升也被忽略
+func TestHotKeyDetector_PinVersion(t *testing.T) {
+	detector := NewHotKeyDetector(2, time.Minute)
+	defer detector.Stop()
+
+	detector.pin("k", makeByteView("v2"), 2, time.Minute)
+	detector.pin("k", makeByteView("v1"), 1, time.Minute)
+	if v, _ := detector.PeekHot("k"); v.String() != "v2" {
+		t.Fatalf("stale promote applied, value = %q", v.String())
+	}
+	if detector.unpin("k", 1, time.Minute) {
+		t.Fatal("stale demote should be ignored")
+	}
+
+	if !detector.unpin("k", 3, time.Minute) {
+		t.Fatal("demote should remove the pinned key")
+	}
+	detector.pin("k", makeByteView("v2"), 2, time.Minute)
+	if _, ok := detector.PeekHot("k"); ok {
+		t.Fatal("promote older than the demote should be ignored")
+	}
+	detector.pin("k", makeByteView("v4"), 4, time.Minute)
+	if v, _ := detector.PeekHot("k"); v.String() != "v4" {
+		t.Fatalf("newer promote not applied, value = %q", v.String())
+	}
+}
+
+// 推送的热点租约到期后删除，即使一直有命中；续约时返回期间的命中次数
+func TestHotKeyDetector_PinLease(t *testing.T) {
+	detector := NewHotKeyDetector(2, time.Minute)
+	defer detector.Stop()
+
+	detector.pin("k", makeByteView("v"), 1, 50*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if _, ok := detector.GetHot("k"); !ok {
+			t.Fatal("pinned key should be hot")
+		}
+	}
+	if served := detector.pin("k", makeByteView("v"), 2, 50*time.Millisecond); served != 3 {
+		t.Fatalf("served = %d, want 3", served)
+	}
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		detector.GetHot("k")
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := detector.GetHot("k"); ok {
+		t.Fatal("pinned key should expire with its lease")
+	}
+	if detector.Len() != 0 {
+		t.Fatalf("Len = %d after lease expired, want 0", detector.Len())
+	}
+}
+
+// 热点层命中的合并与写入、TopKeys、周期衰减并发时不会重复合并
+func TestHotKeyDetector_ConcurrentFlush(t *testing.T) {
+	detector := NewHotKeyDetector(2, 5*time.Millisecond)
+	defer detector.Stop()
+	for i := 0; i < 3; i++ {
+		detector.RecordKey("k", makeByteView("v"))
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	loop := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn()
+				}
+			}
+		}()
+	}
+	// 重复合并会让 hits 减成负数回绕
+	var wrapped atomic.Uint64
+	loop(func() {
+		if v, ok := detector.hotKeys.Load("k"); ok {
+			if n := v.(*hotEntry).hits.Load(); n > 1<<32 {
+				wrapped.Store(n)
+			}
+		}
+	})
+	for i := 0; i < 4; i++ {
+		loop(func() { detector.TopKeys(1) })
+		loop(func() { detector.GetHot("k") })
+	}
+	for deadline := time.Now().Add(200 * time.Millisecond); time.Now().Before(deadline); {
+		detector.RecordKey("k", makeByteView("v"))
+		detector.update("k", makeByteView("v2"))
+	}
+	close(stop)
+	wg.Wait()
+
+	if n := wrapped.Load(); n != 0 {
+		t.Fatalf("hot tier hits = %d, hits merged twice", n)
+	}
+	if n := detector.cms.Count("k"); n > 1<<32 {
+		t.Fatalf("sketch count = %d, hits merged twice", n)
+	}
+	for _, item := range detector.TopKeys(1) {
+		if item.Count > 1<<32 {
+			t.Fatalf("count of %s = %d, hits merged twice", item.Key, item.Count)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package distcache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHotKeyLease 是推送给其他节点的热点的默认租约，主节点每隔 1/3 租约为仍然是热点的 key 续约
+	DefaultHotKeyLease = 30 * time.Second
+	// MinHotKeyLease 是热点租约的下限，更短的租约按下限处理
+	MinHotKeyLease = 30 * time.Millisecond
+)
+
+// hotPush 是等待推送的热点状态，同一个 key 只保留最新的一个
+type hotPush struct {
+	value   ByteView
+	hot     bool
+	version uint64
+}
+
+// isOwner 判断本节点是否是 key 的主节点，未配置节点时总是主节点
+func (g *Group) isOwner(key string) bool {
+	if g.peers == nil {
+		return true
+	}
+	_, remote := g.peers.PickPeer(key)
+	return !remote
+}
+
+// onHotKeyChange 是本地热点变化的回调，可能在持有分片锁时调用，广播放到后台进行
+// 只有主节点推送；负缓存条目只在本地有效，变成热点时按撤销推送，让其他节点丢弃之前推送的值
+func (g *Group) onHotKeyChange(key string, value ByteView, hot bool) {
+	if g.peers == nil || g.closed.Load() || !g.isOwner(key) {
+		return
+	}
+	if hot && value.negative {
+		hot, value = false, ByteView{}
+	}
+	g.queueHotPush(key, value, hot)
+}
+
+// queueHotPush 为 key 分配新的版本并排队推送
+// 每个 key 同时只有一个推送协程，按版本顺序依次推送，推送期间的多次变化只推送最新的一次
+// 撤销只推送给仍在租约内的已推送热点，从未推送过的 key 不需要撤销
+func (g *Group) queueHotPush(key string, value ByteView, hot bool) {
+	g.hotMu.Lock()
+	if hot {
+		g.hotPushed[key] = time.Now().Add(g.hotLease)
+	} else {
+		until, ok := g.hotPushed[key]
+		if !ok || time.Now().After(until) {
+			delete(g.hotPushed, key)
+			g.hotMu.Unlock()
+			return
+		}
+		delete(g.hotPushed, key)
+	}
+	// 版本取当前时间，保证主节点重启或主节点切换后的版本仍然更新
+	g.hotVersion = max(g.hotVersion+1, uint64(time.Now().UnixNano()))
+	_, running := g.hotQueue[key]
+	g.hotQueue[key] = &hotPush{value: value, hot: hot, version: g.hotVersion}
+	g.hotMu.Unlock()
+	if !running {
+		go g.runHotPushes(key)
+	}
+}
+
+// runHotPushes 依次推送 key 排队的状态，没有新的状态时退出
+func (g *Group) runHotPushes(key string) {
+	for {
+		g.hotMu.Lock()
+		p := g.hotQueue[key]
+		if p == nil {
+			delete(g.hotQueue, key)
+			g.hotMu.Unlock()
+			return
+		}
+		g.hotQueue[key] = nil
+		g.hotMu.Unlock()
+		g.broadcastHotKey(key, *p)
+	}
+}
+
+// broadcastHotKey 本节点是主节点时，把热点的提升或撤销推送给其他所有节点，等待所有节点返回
+// 提升时其他节点返回的命中次数计入本节点的频率统计，热点的流量转移到其他节点后本节点不会因此降级
+// 节点选择器需要实现 PeerBroadcaster，节点客户端需要实现 HotKeyPusher，否则不广播
+func (g *Group) broadcastHotKey(key string, p hotPush) {
+	if g.closed.Load() || !g.isOwner(key) {
+		return
+	}
+	b, ok := g.peers.(PeerBroadcaster)
+	if !ok {
+		return
+	}
+	var wg sync.WaitGroup
+	var served atomic.Uint64
+	for _, peer := range b.AllPeers() {
+		pusher, ok := peer.(HotKeyPusher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(pusher HotKeyPusher) {
+			defer wg.Done()
+			var err error
+			if p.hot {
+				var n uint64
+				n, err = pusher.PromoteHotKey(context.Background(), g.name, key, p.value, p.version, g.hotLease)
+				served.Add(n)
+			} else {
+				err = pusher.DemoteHotKey(context.Background(), g.name, key, p.version, g.hotLease)
+			}
+			if err != nil && IsLoggingEnabled() {
+				log.Printf("[DistCache] push hot key %s to peer failed: %v", key, err)
+			}
+		}(pusher)
+	}
+	wg.Wait()
+	if p.hot {
+		// 其他节点的租约从收到推送时开始，推送完成后延长记录的租约
+		g.hotMu.Lock()
+		if _, ok := g.hotPushed[key]; ok {
+			g.hotPushed[key] = time.Now().Add(g.hotLease)
+		}
+		g.hotMu.Unlock()
+	}
+	if n := served.Load(); n > 0 {
+		g.mainCache.hotDetector.addHits(key, n)
+	}
+}
+
+// renewHotKeys 定期为本节点作为主节点的热点续约，直到 Group 关闭
+func (g *Group) renewHotKeys() {
+	ticker := time.NewTicker(g.hotLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if g.peers == nil {
+				continue
+			}
+			now := time.Now()
+			g.hotMu.Lock()
+			for key, until := range g.hotPushed {
+				if now.After(until) {
+					delete(g.hotPushed, key)
+				}
+			}
+			g.hotMu.Unlock()
+			g.mainCache.hotDetector.rangeLocal(func(key string, value ByteView) {
+				if !value.negative && g.isOwner(key) {
+					g.queueHotPush(key, value, true)
+				}
+			})
+		case <-g.hotStop:
+			return
+		}
+	}
+}
+
+// pinHotKey 把主节点推送的热点写入本地热点层，之后的读取直接在本地命中，租约到期后删除
+// 返回上次推送以来该热点在本地的命中次数
+func (g *Group) pinHotKey(key string, value ByteView, version uint64, lease time.Duration) uint64 {
+	if g.closed.Load() || value.expired(time.Now()) {
+		return 0
+	}
+	return g.mainCache.hotDetector.pin(key, value, version, lease)
+}
+
+// unpinHotKey 撤销本地热点层中的 key，lease 内忽略版本更旧的提升
+func (g *Group) unpinHotKey(key string, version uint64, lease time.Duration) {
+	g.mainCache.hotDetector.unpin(key, version, lease)
+}
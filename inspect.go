@@ -0,0 +1,169 @@
+package distcache
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntryInfo 是本地缓存中一个条目的元数据
+type EntryInfo struct {
+	// Size 是 key 和 value 的字节数之和，value 被压缩时按压缩后的大小计算
+	Size int64
+	// Inserted 是条目写入本地缓存的时间，只在热点层中的条目为零值
+	Inserted time.Time
+	// LastAccess 是最近一次从分片命中的时间，热点层的命中不更新
+	LastAccess time.Time
+	// AccessCount 是写入后从分片命中的次数，热点层的命中不计入
+	AccessCount uint64
+	// TTL 是剩余有效期，0 表示永不过期
+	TTL time.Duration
+	// Hot 表示条目已经被提升到热点层
+	Hot bool
+	// Owner 是 key 所属主节点的地址，本节点就是主节点时为空
+	Owner string
+}
+
+// HotKeyInfo 是 Group.HotKeys 返回的一个 key 及其估计访问次数
+type HotKeyInfo struct {
+	Key string `json:"key"`
+	// Count 是本节点上估计的访问次数，只会偏高，最多偏高 Error；每个衰减周期减半
+	Count uint64 `json:"count"`
+	Error uint64 `json:"error"`
+	// Hot 表示 key 当前在热点层中
+	Hot bool `json:"hot"`
+}
+
+// Peek 查看 key 是否在本地缓存中并返回其值，不会加载数据，
+// 也不会影响淘汰顺序、热点统计和命中指标
+func (g *Group) Peek(key string) (ByteView, bool) {
+	if g.closed.Load() {
+		return ByteView{}, false
+	}
+	v, ok := g.mainCache.peek(key)
+	if !ok || v.negative {
+		return ByteView{}, false
+	}
+	return v, true
+}
+
+// Contains 判断 key 是否在本地缓存中，与 Peek 一样不影响缓存状态
+func (g *Group) Contains(key string) bool {
+	_, ok := g.Peek(key)
+	return ok
+}
+
+// EntryInfo 返回 key 在本地缓存中的元数据，不在缓存中时返回 false
+func (g *Group) EntryInfo(key string) (EntryInfo, bool) {
+	if g.closed.Load() {
+		return EntryInfo{}, false
+	}
+	info := EntryInfo{Hot: g.mainCache.isHot(key), Owner: g.ownerAddr(key)}
+	var value ByteView
+	if entry, ok := g.mainCache.peekEntry(key); ok {
+		value = entry.value
+		info.Inserted = entry.inserted
+		info.LastAccess = entry.lastAccess
+		info.AccessCount = entry.accesses
+	} else if v, ok := g.mainCache.peek(key); ok {
+		value = v
+	} else {
+		return EntryInfo{}, false
+	}
+	if value.negative {
+		return EntryInfo{}, false
+	}
+	info.Size = int64(len(key) + value.size())
+	if !value.expire.IsZero() {
+		info.TTL = time.Until(value.expire)
+	}
+	return info, true
+}
+
+// Range 遍历本地缓存中未过期的条目，fn 返回 false 时停止
+// 遍历逐个分片进行，不会同时持有所有分片锁，遍历期间的写入不保证可见；不包括只在热点层中的条目
+func (g *Group) Range(fn func(key string, value ByteView) bool) {
+	if g.closed.Load() {
+		return
+	}
+	g.mainCache.rangeEntries(func(key string, entry cacheEntry) bool {
+		if entry.value.negative {
+			return true
+		}
+		return fn(key, entry.value)
+	})
+}
+
+// Keys 返回本地缓存中以 prefix 开头的 key，按字典序排序，prefix 为空时返回全部
+func (g *Group) Keys(prefix string) []string {
+	var keys []string
+	g.Range(func(key string, _ ByteView) bool {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// keysPage 分页列出本地缓存中以 prefix 开头的 key，cursor 为空表示第一页，之后传入上一页返回的 next
+// 每页只扫描需要的分片，不复制值；key 按分片顺序返回，同一分片内按字典序，next 为空表示没有更多
+// 翻页期间写入的 key 可能出现也可能不出现
+func (g *Group) keysPage(prefix, cursor string, limit int) (keys []string, next string, err error) {
+	if g.closed.Load() {
+		return nil, "", nil
+	}
+	shard, after := 0, ""
+	if cursor != "" {
+		i := strings.IndexByte(cursor, ':')
+		if i < 0 {
+			return nil, "", fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		if shard, err = strconv.Atoi(cursor[:i]); err != nil || shard < 0 {
+			return nil, "", fmt.Errorf("invalid cursor: %s", cursor)
+		}
+		after = cursor[i+1:]
+	}
+	keys, nextShard, last := g.mainCache.scanKeys(prefix, shard, after, limit)
+	if nextShard >= 0 {
+		next = strconv.Itoa(nextShard) + ":" + last
+	}
+	return keys, next, nil
+}
+
+// HotKeys 返回本节点上访问次数最多的 n 个 key，按估计次数从高到低排序，n <= 0 时返回全部被跟踪的 key
+// 次数由 Space-Saving 算法估计，包括本地命中、热点层命中和写入
+func (g *Group) HotKeys(n int) []HotKeyInfo {
+	if g.closed.Load() {
+		return nil
+	}
+	items := g.mainCache.hotDetector.TopKeys(n)
+	keys := make([]HotKeyInfo, len(items))
+	for i, item := range items {
+		keys[i] = HotKeyInfo{
+			Key:   item.Key,
+			Count: item.Count,
+			Error: item.Error,
+			Hot:   g.mainCache.isHot(item.Key),
+		}
+	}
+	return keys
+}
+
+// ownerAddr 返回 key 所属主节点的地址，本节点是主节点时返回空字符串
+func (g *Group) ownerAddr(key string) string {
+	if g.peers == nil {
+		return ""
+	}
+	peer, ok := g.peers.PickPeer(key)
+	if !ok {
+		return ""
+	}
+	if p, ok := peer.(PeerAddr); ok {
+		return p.Addr()
+	}
+	return "remote"
+}
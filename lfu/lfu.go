@@ -0,0 +1,186 @@
+package lfu
+
+import (
+	"container/list"
+	"sort"
+
+	"github.com/simplely77/distcache/lru"
+)
+
+// Value 与 lru.Value 相同，使不同的淘汰策略可以互相替换
+type Value = lru.Value
+
+// Cache 是按访问频率淘汰的缓存，频率相同时淘汰最久未使用的条目
+type Cache struct {
+	// 最大内存
+	maxBytes int64
+	// 当前内存
+	nbytes int64
+	// 键值对映射
+	cache map[string]*list.Element
+	// 访问频率 -> 该频率下的条目链表，队首为最近使用
+	freqs map[int]*list.List
+	// 当前最小的访问频率
+	minFreq int
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted func(key string, val Value)
+}
+
+type entry struct {
+	key   string
+	value Value
+	freq  int
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		cache:     make(map[string]*list.Element),
+		freqs:     make(map[int]*list.List),
+		onEvicted: onEvicted,
+	}
+}
+
+// Add 添加一个键值对到缓存中，已存在的键会更新值并增加访问频率
+func (c *Cache) Add(key string, val Value) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		c.nbytes += int64(val.Len()) - int64(kv.value.Len())
+		kv.value = val
+		c.touch(ele)
+	} else {
+		kv := &entry{key: key, value: val, freq: 1}
+		c.cache[key] = c.list(1).PushFront(kv)
+		c.minFreq = 1
+		c.nbytes += int64(len(key)) + int64(val.Len())
+	}
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+	}
+}
+
+// Get 查找键对应的值，并增加访问频率
+func (c *Cache) Get(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		c.touch(ele)
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Peek 查找键对应的值，但不增加访问频率
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Victim 返回下一个会被淘汰的键，但不淘汰它
+func (c *Cache) Victim() (key string, ok bool) {
+	if ele := c.oldest(); ele != nil {
+		return ele.Value.(*entry).key, true
+	}
+	return
+}
+
+// RemoveOldest 移除访问频率最低的条目中最久未使用的一个
+func (c *Cache) RemoveOldest() {
+	if ele := c.oldest(); ele != nil {
+		c.removeElement(ele)
+	}
+}
+
+// oldest 返回访问频率最低的条目中最久未使用的一个
+func (c *Cache) oldest() *list.Element {
+	if len(c.cache) == 0 {
+		return nil
+	}
+	l, ok := c.freqs[c.minFreq]
+	if !ok {
+		// minFreq 所在的链表已被 Remove 清空，重新计算
+		c.minFreq = 0
+		for f := range c.freqs {
+			if c.minFreq == 0 || f < c.minFreq {
+				c.minFreq = f
+			}
+		}
+		l = c.freqs[c.minFreq]
+	}
+	return l.Back()
+}
+
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.removeElement(ele)
+	}
+}
+
+// Range 按淘汰顺序遍历条目：访问频率从低到高，同一频率内从最久未使用到最近使用，
+// fn 返回 false 时停止，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	freqs := make([]int, 0, len(c.freqs))
+	for f := range c.freqs {
+		freqs = append(freqs, f)
+	}
+	sort.Ints(freqs)
+	for _, f := range freqs {
+		for ele := c.freqs[f].Back(); ele != nil; ele = ele.Prev() {
+			kv := ele.Value.(*entry)
+			if !fn(kv.key, kv.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// NBytes 返回当前缓存使用的字节数
+func (c *Cache) NBytes() int64 {
+	return c.nbytes
+}
+
+// touch 将条目移动到下一个频率的链表
+func (c *Cache) touch(ele *list.Element) {
+	kv := ele.Value.(*entry)
+	c.unlink(ele)
+	if kv.freq == c.minFreq {
+		if _, ok := c.freqs[kv.freq]; !ok {
+			c.minFreq++
+		}
+	}
+	kv.freq++
+	c.cache[kv.key] = c.list(kv.freq).PushFront(kv)
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	kv := ele.Value.(*entry)
+	c.unlink(ele)
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.onEvicted != nil {
+		c.onEvicted(kv.key, kv.value)
+	}
+}
+
+// unlink 将条目从所在频率的链表中移除，链表为空时删除该频率
+func (c *Cache) unlink(ele *list.Element) {
+	freq := ele.Value.(*entry).freq
+	l := c.freqs[freq]
+	l.Remove(ele)
+	if l.Len() == 0 {
+		delete(c.freqs, freq)
+	}
+}
+
+func (c *Cache) list(freq int) *list.List {
+	l, ok := c.freqs[freq]
+	if !ok {
+		l = list.New()
+		c.freqs[freq] = l
+	}
+	return l
+}
@@ -0,0 +1,101 @@
+package lfu
+
+import (
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+// test Get method of Cache
+func TestGet(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Add("key1", String("1234"))
+	if v, ok := lfu.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := lfu.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+// test the least frequently used entry is evicted
+func TestRemoveLeastFrequent(t *testing.T) {
+	k1, k2, k3 := "key1", "key2", "k3"
+	v1, v2, v3 := "value1", "value2", "v3"
+	cap := len(k1 + k2 + v1 + v2)
+	lfu := New(int64(cap), nil)
+	lfu.Add(k1, String(v1))
+	lfu.Add(k2, String(v2))
+	// key1 被访问过，频率高于 key2
+	lfu.Get(k1)
+	lfu.Add(k3, String(v3))
+	if _, ok := lfu.Get(k2); ok || lfu.Len() != 2 {
+		t.Fatalf("RemoveOldest key2 failed")
+	}
+	if _, ok := lfu.Get(k1); !ok {
+		t.Fatalf("frequent key1 should be kept")
+	}
+}
+
+// test OnEvicted callback function of Cache
+func TestOnEvicted(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	lfu := New(int64(10), callback)
+	lfu.Add("key1", String("123456"))
+	lfu.Add("k2", String("v2"))
+	lfu.Add("k3", String("v3"))
+	lfu.Add("k4", String("v4"))
+	expect := []string{"key1", "k2"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("OnEvicted keys = %v, want %v", keys, expect)
+	}
+}
+
+// test NBytes stays consistent after updates and removals
+func TestNBytes(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Add("k1", String("v1"))
+	lfu.Add("k1", String("value1"))
+	lfu.Add("k2", String("v2"))
+	lfu.Remove("k1")
+	if lfu.NBytes() != int64(len("k2v2")) || lfu.Len() != 1 {
+		t.Fatalf("NBytes = %d, Len = %d", lfu.NBytes(), lfu.Len())
+	}
+	lfu.RemoveOldest()
+	if lfu.NBytes() != 0 || lfu.Len() != 0 {
+		t.Fatalf("cache should be empty, NBytes = %d", lfu.NBytes())
+	}
+}
+
+// test Range walks entries in eviction order
+func TestRange(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Add("k1", String("v1"))
+	lfu.Add("k2", String("v2"))
+	lfu.Add("k3", String("v3"))
+	lfu.Get("k1")
+	lfu.Get("k1")
+	lfu.Get("k2")
+
+	keys := make([]string, 0)
+	lfu.Range(func(key string, value Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	expect := []string{"k3", "k2", "k1"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("Range keys = %v, want %v", keys, expect)
+	}
+	victim, _ := lfu.Victim()
+	if victim != keys[0] {
+		t.Fatalf("Range should start with the victim %s, got %s", victim, keys[0])
+	}
+}
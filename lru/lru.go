@@ -85,6 +85,22 @@ func (c *Cache) Get(key string) (value Value, ok bool) {
 	return
 }
 
+// Peek 查找键对应的值，但不改变其在链表中的位置
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Victim 返回下一个会被淘汰的键，但不淘汰它
+func (c *Cache) Victim() (key string, ok bool) {
+	if ele := c.ll.Back(); ele != nil {
+		return ele.Value.(*entry).key, true
+	}
+	return
+}
+
 func (c *Cache) Remove(key string) {
 	if ele, ok := c.cache[key]; ok {
 		c.ll.Remove(ele)
@@ -97,6 +113,16 @@ func (c *Cache) Remove(key string) {
 	}
 }
 
+// Range 从最久未使用到最近使用依次遍历条目，fn 返回 false 时停止，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.ll.Back(); ele != nil; ele = ele.Prev() {
+		kv := ele.Value.(*entry)
+		if !fn(kv.key, kv.value) {
+			return
+		}
+	}
+}
+
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
@@ -23,6 +23,21 @@ type Metrics struct {
 	BloomFilterQueries *prometheus.CounterVec
 	// 当前缓存大小
 	CacheSize *prometheus.GaugeVec
+	// TinyLFU 准入计数器
+	AdmissionsTotal *prometheus.CounterVec
+	// 本地缓存淘汰计数器
+	EvictionsTotal *prometheus.CounterVec
+	// 共享预算分给各组的容量
+	BudgetShare *prometheus.GaugeVec
+	// 磁盘层占用的字节数
+	DiskSize *prometheus.GaugeVec
+	// 写入磁盘层的条目数
+	DiskSpillsTotal *prometheus.CounterVec
+	// 磁盘层段压缩次数
+	DiskCompactionsTotal *prometheus.CounterVec
+	// 热点层中的 key 数和字节数
+	HotTierKeys  *prometheus.GaugeVec
+	HotTierBytes *prometheus.GaugeVec
 }
 
 var (
@@ -54,7 +69,7 @@ func NewMetrics() *Metrics {
 				Name: "distcache_hits_total",
 				Help: "The total number of cache hits",
 			},
-			[]string{"type"}, // local, hot, remote
+			[]string{"type"}, // local, hot, disk, remote
 		),
 		HotKeyHitsTotal: promauto.NewCounter(
 			prometheus.CounterOpts{
@@ -67,7 +82,7 @@ func NewMetrics() *Metrics {
 				Name: "distcache_hot_keys_total",
 				Help: "The total number of hot keys identified",
 			},
-			[]string{"action"}, // promoted, demoted
+			[]string{"action"}, // promoted, demoted, evicted
 		),
 		RequestDuration: promauto.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -91,6 +106,62 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"group"},
 		),
+		AdmissionsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "distcache_admissions_total",
+				Help: "The total number of TinyLFU admission decisions",
+			},
+			[]string{"result"}, // admitted, rejected
+		),
+		EvictionsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "distcache_evictions_total",
+				Help: "The total number of entries removed from the local cache",
+			},
+			[]string{"group", "reason"}, // capacity, expired, deleted, replaced
+		),
+		BudgetShare: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "distcache_budget_share_bytes",
+				Help: "The cache capacity currently allocated to a group from a shared budget",
+			},
+			[]string{"group"},
+		),
+		DiskSize: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "distcache_disk_size_bytes",
+				Help: "The current size of the disk tier in bytes",
+			},
+			[]string{"group"},
+		),
+		DiskSpillsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "distcache_disk_spills_total",
+				Help: "The total number of entries spilled from memory to the disk tier",
+			},
+			[]string{"group"},
+		),
+		DiskCompactionsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "distcache_disk_compactions_total",
+				Help: "The total number of disk tier segment compactions",
+			},
+			[]string{"group"},
+		),
+		HotTierKeys: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "distcache_hot_tier_keys",
+				Help: "The current number of keys promoted to the hot tier",
+			},
+			[]string{"group"},
+		),
+		HotTierBytes: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "distcache_hot_tier_bytes",
+				Help: "The current size of the hot tier in bytes",
+			},
+			[]string{"group"},
+		),
 	}
 }
 
@@ -124,11 +195,47 @@ func (m *Metrics) RecordBloomFilter(result string) {
 	m.BloomFilterQueries.WithLabelValues(result).Inc()
 }
 
+// RecordAdmission 记录 TinyLFU 准入结果
+func (m *Metrics) RecordAdmission(result string) {
+	m.AdmissionsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordEviction 记录本地缓存淘汰
+func (m *Metrics) RecordEviction(group, reason string) {
+	m.EvictionsTotal.WithLabelValues(group, reason).Inc()
+}
+
 // SetCacheSize 设置缓存大小
 func (m *Metrics) SetCacheSize(group string, size int64) {
 	m.CacheSize.WithLabelValues(group).Set(float64(size))
 }
 
+// SetBudgetShare 设置共享预算分给某个组的容量
+func (m *Metrics) SetBudgetShare(group string, size int64) {
+	m.BudgetShare.WithLabelValues(group).Set(float64(size))
+}
+
+// SetDiskSize 设置磁盘层大小
+func (m *Metrics) SetDiskSize(group string, size int64) {
+	m.DiskSize.WithLabelValues(group).Set(float64(size))
+}
+
+// SetHotTierSize 设置热点层的 key 数和字节数
+func (m *Metrics) SetHotTierSize(group string, keys int, bytes int64) {
+	m.HotTierKeys.WithLabelValues(group).Set(float64(keys))
+	m.HotTierBytes.WithLabelValues(group).Set(float64(bytes))
+}
+
+// RecordDiskSpill 记录一次写入磁盘层
+func (m *Metrics) RecordDiskSpill(group string) {
+	m.DiskSpillsTotal.WithLabelValues(group).Inc()
+}
+
+// RecordDiskCompaction 记录一次磁盘层段压缩
+func (m *Metrics) RecordDiskCompaction(group string) {
+	m.DiskCompactionsTotal.WithLabelValues(group).Inc()
+}
+
 // EnableMetrics 启用 Prometheus 指标收集（可选调用）
 // 如果不调用此函数，指标收集将被禁用
 var metricsEnabled bool
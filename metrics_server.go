@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -32,6 +33,12 @@ func (ms *MetricsServer) Start() error {
 	// 健康检查端点
 	mux.HandleFunc("/health", ms.healthHandler)
 
+	// 分页查看缓存中的 key，用于调试
+	mux.HandleFunc("/debug/keys", ms.keysHandler)
+
+	// 查看访问次数最多的 key
+	mux.HandleFunc("/debug/hotkeys", ms.hotKeysHandler)
+
 	ms.server = &http.Server{
 		Addr:    ms.addr,
 		Handler: mux,
@@ -61,6 +68,81 @@ func (ms *MetricsServer) healthHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// 调试接口每页 key 数量的默认值和上限
+const (
+	defaultKeysPageSize = 100
+	maxKeysPageSize     = 1000
+)
+
+// keysHandler 分页列出某个 Group 本地缓存中的 key，每页只扫描需要的分片
+// 参数：group（必填）、prefix、after（上一页返回的 next）、limit
+func (ms *MetricsServer) keysHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	group := GetGroup(q.Get("group"))
+	if group == nil {
+		http.Error(w, "no such group: "+q.Get("group"), http.StatusNotFound)
+		return
+	}
+	limit := defaultKeysPageSize
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit: "+s, http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxKeysPageSize)
+	}
+
+	page, next, err := group.keysPage(q.Get("prefix"), q.Get("after"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page == nil {
+		page = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group": group.name,
+		"keys":  page,
+		"next":  next,
+	})
+}
+
+// 热点 key 调试接口返回数量的默认值
+const defaultHotKeysLimit = 10
+
+// hotKeysHandler 列出某个 Group 访问次数最多的 key
+// 参数：group（必填）、n（返回数量，上限与 /debug/keys 的每页数量相同）
+func (ms *MetricsServer) hotKeysHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	group := GetGroup(q.Get("group"))
+	if group == nil {
+		http.Error(w, "no such group: "+q.Get("group"), http.StatusNotFound)
+		return
+	}
+	n := defaultHotKeysLimit
+	if s := q.Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			http.Error(w, "invalid n: "+s, http.StatusBadRequest)
+			return
+		}
+		n = min(v, maxKeysPageSize)
+	}
+
+	keys := group.HotKeys(n)
+	if keys == nil {
+		keys = []HotKeyInfo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group": group.name,
+		"keys":  keys,
+	})
+}
+
 // StartMetricsServer 启动监控服务器（阻塞模式）
 func StartMetricsServer(addr string) error {
 	server := NewMetricsServer(addr)
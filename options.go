@@ -0,0 +1,213 @@
+package distcache
+
+import (
+	"time"
+
+	"github.com/simplely77/distcache/diskcache"
+)
+
+// groupOptions 是创建 Group 的配置，通过 Option 修改
+type groupOptions struct {
+	cacheBytes    int64
+	hotThreshold  uint64
+	decayInterval time.Duration
+	hotMaxKeys    int
+	hotMaxBytes   int64
+	shardCount    int
+	hash          HashFunc
+	policy        PolicyFactory
+	admission     bool
+	compressor    compressor
+	ttl           time.Duration
+	negativeTTL   time.Duration
+	refreshAhead  time.Duration
+	peers         PeerPicker
+	budget        *CacheBudget
+	snapshotPath  string
+	disk          *diskcache.Options
+	budgetWeight  float64
+	// 布隆过滤器配置，expectedKeys 为 0 表示不启用
+	bloomExpectedKeys uint
+	bloomFPRate       float64
+	bloomKeys         []string
+	bloomComplete     bool
+	// 是否把本节点作为主节点时的热点广播给其他节点
+	hotReplication bool
+	hotLease       time.Duration
+}
+
+func defaultGroupOptions() groupOptions {
+	return groupOptions{
+		hotThreshold:  DefaultHotKeyThreshold,
+		decayInterval: DefaultDecayInterval,
+		shardCount:    DefaultShardCount,
+		policy:        LRUPolicy,
+		negativeTTL:   DefaultNegativeTTL,
+		hotLease:      DefaultHotKeyLease,
+	}
+}
+
+// Option 是 NewGroupWithOptions 的可选配置
+type Option func(*groupOptions)
+
+// WithCacheBytes 设置本地缓存的最大字节数，0 表示不限制
+func WithCacheBytes(cacheBytes int64) Option {
+	return func(o *groupOptions) {
+		o.cacheBytes = cacheBytes
+	}
+}
+
+// WithHotKeyThreshold 设置热点检测阈值
+func WithHotKeyThreshold(threshold uint64) Option {
+	return func(o *groupOptions) {
+		o.hotThreshold = threshold
+	}
+}
+
+// WithHotKeyLimit 设置热点层最多保存的 key 数和字节数，热点层已满时淘汰频率最低的热点
+// 为 0 时使用默认值（DefaultHotKeyMaxKeys 和 cacheBytes/DefaultHotKeyBytesRatio），小于 0 表示不限制
+func WithHotKeyLimit(maxKeys int, maxBytes int64) Option {
+	return func(o *groupOptions) {
+		o.hotMaxKeys = maxKeys
+		o.hotMaxBytes = maxBytes
+	}
+}
+
+// WithDecayInterval 设置热点频率衰减周期
+func WithDecayInterval(interval time.Duration) Option {
+	return func(o *groupOptions) {
+		o.decayInterval = interval
+	}
+}
+
+// WithShardCount 设置本地缓存的分片数，不是 2 的幂时向上取整
+func WithShardCount(n int) Option {
+	return func(o *groupOptions) {
+		o.shardCount = n
+	}
+}
+
+// WithHashFunc 设置把 key 映射到本地缓存分片的哈希函数，默认 FNV1aHash
+// 只影响本地分片，不影响节点间的一致性哈希
+func WithHashFunc(hash HashFunc) Option {
+	return func(o *groupOptions) {
+		o.hash = hash
+	}
+}
+
+// WithEvictionPolicy 设置缓存分片的淘汰策略，内置 LRUPolicy（默认）、LFUPolicy、ARCPolicy、SIEVEPolicy
+func WithEvictionPolicy(policy PolicyFactory) Option {
+	return func(o *groupOptions) {
+		o.policy = policy
+	}
+}
+
+// WithTinyLFUAdmission 启用 TinyLFU 准入过滤：分片已满时，只有访问频率高于淘汰候选的新 key 才会写入
+// 准入只作用于加载的数据，Set 和副本同步等显式写入总是生效
+func WithTinyLFUAdmission() Option {
+	return func(o *groupOptions) {
+		o.admission = true
+	}
+}
+
+// WithCompression 对不小于 threshold 字节的值使用 codec 压缩，threshold <= 0 时使用 DefaultCompressionThreshold
+// 值以压缩形式保存在本地缓存并在节点间传输，读取时按需解压；压缩后没有变小的值按原样保存
+func WithCompression(codec Compression, threshold int) Option {
+	return func(o *groupOptions) {
+		if threshold <= 0 {
+			threshold = DefaultCompressionThreshold
+		}
+		o.compressor = compressor{codec: codec, threshold: threshold}
+	}
+}
+
+// WithHotKeyReplication 启用热点复制：本节点作为主节点时，新晋升的热点及其更新和降级会通过
+// PeerBroadcaster 推送给其他所有节点，使它们直接在本地提供热点而不再转发到主节点；
+// Delete 会撤销所有节点上的热点。推送带有递增的版本，其他节点忽略更旧的推送；
+// 推送的热点有租约（见 WithHotKeyLease），主节点为仍是热点的 key 定期续约，并把其他节点的命中计入频率，
+// 其他节点收到的热点在租约到期或一个衰减周期内没有命中时自动删除
+func WithHotKeyReplication() Option {
+	return func(o *groupOptions) {
+		o.hotReplication = true
+	}
+}
+
+// WithHotKeyLease 设置推送给其他节点的热点的租约，默认 DefaultHotKeyLease，
+// 小于 MinHotKeyLease 时按 MinHotKeyLease 处理，需要同时启用 WithHotKeyReplication
+func WithHotKeyLease(lease time.Duration) Option {
+	return func(o *groupOptions) {
+		if lease > 0 {
+			o.hotLease = max(lease, MinHotKeyLease)
+		}
+	}
+}
+
+// WithTTL 设置默认过期时间，见 Group.SetDefaultTTL
+func WithTTL(ttl time.Duration) Option {
+	return func(o *groupOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithNegativeTTL 设置负缓存过期时间，见 Group.SetNegativeTTL
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *groupOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithRefreshAhead 设置提前刷新窗口，见 Group.SetRefreshAhead
+func WithRefreshAhead(window time.Duration) Option {
+	return func(o *groupOptions) {
+		o.refreshAhead = window
+	}
+}
+
+// WithBloomFilter 启用布隆过滤器，见 Group.EnableBloomFilter
+func WithBloomFilter(expectedKeys uint, falsePositiveRate float64, keys ...string) Option {
+	return func(o *groupOptions) {
+		o.bloomExpectedKeys = expectedKeys
+		o.bloomFPRate = falsePositiveRate
+		o.bloomKeys = keys
+	}
+}
+
+// WithCompleteBloomFilter 启用布隆过滤器，并声明 keys 是数据源中全部的 key，见 Group.SetBloomFilterComplete
+func WithCompleteBloomFilter(expectedKeys uint, falsePositiveRate float64, keys ...string) Option {
+	return func(o *groupOptions) {
+		WithBloomFilter(expectedKeys, falsePositiveRate, keys...)(o)
+		o.bloomComplete = true
+	}
+}
+
+// WithPeerPicker 在创建时注册节点选择器，见 Group.RegisterPeers
+func WithPeerPicker(peers PeerPicker) Option {
+	return func(o *groupOptions) {
+		o.peers = peers
+	}
+}
+
+// WithCacheBudget 让 Group 从共享预算中获得容量，忽略 WithCacheBytes
+// weight 是按权重分配时的比例，按命中价值分配时与命中次数相乘
+func WithCacheBudget(budget *CacheBudget, weight float64) Option {
+	return func(o *groupOptions) {
+		o.budget = budget
+		o.budgetWeight = weight
+	}
+}
+
+// WithSnapshotPath 创建 Group 时从 path 加载快照（文件不存在时忽略），
+// GRPCPool.Stop 或 SaveSnapshots 时把本地缓存写回 path，用于重启后预热
+func WithSnapshotPath(path string) Option {
+	return func(o *groupOptions) {
+		o.snapshotPath = path
+	}
+}
+
+// WithDiskTier 在内存分片下增加本地磁盘层：因容量不足被淘汰的条目写入磁盘，
+// Get 在内存未命中时先查磁盘再查远程节点和数据源，磁盘层有独立的容量上限并在后台压缩
+func WithDiskTier(opts diskcache.Options) Option {
+	return func(o *groupOptions) {
+		o.disk = &opts
+	}
+}
@@ -1,5 +1,10 @@
 package distcache
 
+import (
+	"context"
+	"time"
+)
+
 // PeerPicker 选择远程节点的接口，提供了根据键选择节点的方法
 type PeerPicker interface {
 	PickPeer(key string)(peer PeerClient,ok bool)
@@ -7,8 +12,33 @@ type PeerPicker interface {
 }
 
 // PeerClient 获取远程节点数据的接口，副本相关的方法也放在这里
+// ctx 用于传递调用方的截止时间和取消信号
 type PeerClient interface {
-	Get(group string,key string)([]byte,error)
-	Set(group string, key string, value []byte) error
-	Delete(group string,key string)error
-}
\ No newline at end of file
+	// Get 返回的值保持远程节点上的压缩方式，读取时才解压
+	Get(ctx context.Context, group string, key string) (ByteView, error)
+	// Set 写入副本，value 的过期时间会一并传给远程节点
+	Set(ctx context.Context, group string, key string, value ByteView) error
+	Delete(ctx context.Context, group string, key string) error
+	// BatchGet 一次请求获取多个 key，返回结果与 keys 一一对应
+	BatchGet(ctx context.Context, group string, keys []string) ([]GetResult, error)
+}
+
+// PeerAddr 是 PeerClient 可选实现的接口，返回节点地址，用于 EntryInfo 等诊断信息
+type PeerAddr interface {
+	Addr() string
+}
+
+// PeerBroadcaster 是 PeerPicker 可选实现的接口，返回除本节点外的所有节点，用于广播热点
+type PeerBroadcaster interface {
+	AllPeers() []PeerClient
+}
+
+// HotKeyPusher 是 PeerClient 可选实现的接口，主节点通过它把热点的提升和撤销推送给其他节点
+// version 由主节点递增分配，远程节点忽略比已收到的更旧的推送
+type HotKeyPusher interface {
+	// PromoteHotKey 让远程节点在 lease 内把 key 作为热点直接在本地提供，value 的过期时间会一并传递，
+	// 返回远程节点上次推送以来该热点的命中次数
+	PromoteHotKey(ctx context.Context, group string, key string, value ByteView, version uint64, lease time.Duration) (uint64, error)
+	// DemoteHotKey 撤销远程节点上的热点，远程节点在 lease 内忽略版本更旧的提升
+	DemoteHotKey(ctx context.Context, group string, key string, version uint64, lease time.Duration) error
+}
@@ -75,10 +75,16 @@ func (x *GetRequest) GetKey() string {
 }
 
 type GetResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
-	Err           string                 `protobuf:"bytes,3,opt,name=err,proto3" json:"err,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Found bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Err   string                 `protobuf:"bytes,3,opt,name=err,proto3" json:"err,omitempty"`
+	// key 在数据源中不存在（区别于其他错误）
+	NotFound bool `protobuf:"varint,4,opt,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	// data 的压缩方式，0 表示未压缩
+	Codec uint32 `protobuf:"varint,5,opt,name=codec,proto3" json:"codec,omitempty"`
+	// 压缩前的长度，0 表示未知（接收方解压一次得到）
+	RawSize       uint64 `protobuf:"varint,6,opt,name=raw_size,json=rawSize,proto3" json:"raw_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -134,19 +140,152 @@ func (x *GetResponse) GetErr() string {
 	return ""
 }
 
-// --------- Set / Populate ---------
-type SetRequest struct {
+func (x *GetResponse) GetNotFound() bool {
+	if x != nil {
+		return x.NotFound
+	}
+	return false
+}
+
+func (x *GetResponse) GetCodec() uint32 {
+	if x != nil {
+		return x.Codec
+	}
+	return 0
+}
+
+func (x *GetResponse) GetRawSize() uint64 {
+	if x != nil {
+		return x.RawSize
+	}
+	return 0
+}
+
+// --------- BatchGet ---------
+type BatchGetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
-	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_proto_distcache_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_distcache_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_distcache_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchGetRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *BatchGetRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type BatchGetResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// results[i] 对应请求中的 keys[i]
+	Results       []*GetResponse `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Err           string         `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_proto_distcache_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_distcache_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_distcache_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetResponse) GetResults() []*GetResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchGetResponse) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// --------- Set / Populate ---------
+type SetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Group string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Data  []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// 过期时间（Unix 纳秒），0 表示永不过期；使用绝对时间使副本与主节点同时过期
+	ExpireAt int64 `protobuf:"varint,4,opt,name=expire_at,json=expireAt,proto3" json:"expire_at,omitempty"`
+	// data 的压缩方式，0 表示未压缩
+	Codec uint32 `protobuf:"varint,5,opt,name=codec,proto3" json:"codec,omitempty"`
+	// 压缩前的长度，0 表示未知（接收方解压一次得到）
+	RawSize       uint64 `protobuf:"varint,6,opt,name=raw_size,json=rawSize,proto3" json:"raw_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SetRequest) Reset() {
 	*x = SetRequest{}
-	mi := &file_proto_distcache_proto_msgTypes[2]
+	mi := &file_proto_distcache_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -158,7 +297,7 @@ func (x *SetRequest) String() string {
 func (*SetRequest) ProtoMessage() {}
 
 func (x *SetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_distcache_proto_msgTypes[2]
+	mi := &file_proto_distcache_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -171,7 +310,7 @@ func (x *SetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetRequest.ProtoReflect.Descriptor instead.
 func (*SetRequest) Descriptor() ([]byte, []int) {
-	return file_proto_distcache_proto_rawDescGZIP(), []int{2}
+	return file_proto_distcache_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SetRequest) GetGroup() string {
@@ -195,6 +334,27 @@ func (x *SetRequest) GetData() []byte {
 	return nil
 }
 
+func (x *SetRequest) GetExpireAt() int64 {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return 0
+}
+
+func (x *SetRequest) GetCodec() uint32 {
+	if x != nil {
+		return x.Codec
+	}
+	return 0
+}
+
+func (x *SetRequest) GetRawSize() uint64 {
+	if x != nil {
+		return x.RawSize
+	}
+	return 0
+}
+
 type SetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -205,7 +365,7 @@ type SetResponse struct {
 
 func (x *SetResponse) Reset() {
 	*x = SetResponse{}
-	mi := &file_proto_distcache_proto_msgTypes[3]
+	mi := &file_proto_distcache_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -217,7 +377,7 @@ func (x *SetResponse) String() string {
 func (*SetResponse) ProtoMessage() {}
 
 func (x *SetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_distcache_proto_msgTypes[3]
+	mi := &file_proto_distcache_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -230,7 +390,7 @@ func (x *SetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetResponse.ProtoReflect.Descriptor instead.
 func (*SetResponse) Descriptor() ([]byte, []int) {
-	return file_proto_distcache_proto_rawDescGZIP(), []int{3}
+	return file_proto_distcache_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SetResponse) GetSuccess() bool {
@@ -258,7 +418,7 @@ type DeleteRequest struct {
 
 func (x *DeleteRequest) Reset() {
 	*x = DeleteRequest{}
-	mi := &file_proto_distcache_proto_msgTypes[4]
+	mi := &file_proto_distcache_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -270,7 +430,7 @@ func (x *DeleteRequest) String() string {
 func (*DeleteRequest) ProtoMessage() {}
 
 func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_distcache_proto_msgTypes[4]
+	mi := &file_proto_distcache_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -283,7 +443,7 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
 func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return file_proto_distcache_proto_rawDescGZIP(), []int{4}
+	return file_proto_distcache_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DeleteRequest) GetGroup() string {
@@ -310,7 +470,7 @@ type DeleteResponse struct {
 
 func (x *DeleteResponse) Reset() {
 	*x = DeleteResponse{}
-	mi := &file_proto_distcache_proto_msgTypes[5]
+	mi := &file_proto_distcache_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -322,7 +482,7 @@ func (x *DeleteResponse) String() string {
 func (*DeleteResponse) ProtoMessage() {}
 
 func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_distcache_proto_msgTypes[5]
+	mi := &file_proto_distcache_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -335,7 +495,7 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
 func (*DeleteResponse) Descriptor() ([]byte, []int) {
-	return file_proto_distcache_proto_rawDescGZIP(), []int{5}
+	return file_proto_distcache_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteResponse) GetSuccess() bool {
@@ -352,6 +512,180 @@ func (x *DeleteResponse) GetErr() string {
 	return ""
 }
 
+// --------- HotKey ---------
+type HotKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Group string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// true 表示把 key 提升为热点（或更新热点的值），false 表示撤销热点
+	Promote bool `protobuf:"varint,3,opt,name=promote,proto3" json:"promote,omitempty"`
+	// 以下字段只在 promote 为 true 时有效，含义与 SetRequest 相同
+	Data     []byte `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	ExpireAt int64  `protobuf:"varint,5,opt,name=expire_at,json=expireAt,proto3" json:"expire_at,omitempty"`
+	Codec    uint32 `protobuf:"varint,6,opt,name=codec,proto3" json:"codec,omitempty"`
+	RawSize  uint64 `protobuf:"varint,7,opt,name=raw_size,json=rawSize,proto3" json:"raw_size,omitempty"`
+	// 主节点为每次推送分配的递增版本，接收方忽略比已收到的更旧的推送
+	Version uint64 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	// 租约（毫秒）：提升的热点到期后即使仍有命中也会删除，撤销后在租约内忽略更旧的提升；0 表示不限制
+	LeaseMs       int64 `protobuf:"varint,9,opt,name=lease_ms,json=leaseMs,proto3" json:"lease_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HotKeyRequest) Reset() {
+	*x = HotKeyRequest{}
+	mi := &file_proto_distcache_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HotKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HotKeyRequest) ProtoMessage() {}
+
+func (x *HotKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_distcache_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HotKeyRequest.ProtoReflect.Descriptor instead.
+func (*HotKeyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_distcache_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HotKeyRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *HotKeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HotKeyRequest) GetPromote() bool {
+	if x != nil {
+		return x.Promote
+	}
+	return false
+}
+
+func (x *HotKeyRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *HotKeyRequest) GetExpireAt() int64 {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return 0
+}
+
+func (x *HotKeyRequest) GetCodec() uint32 {
+	if x != nil {
+		return x.Codec
+	}
+	return 0
+}
+
+func (x *HotKeyRequest) GetRawSize() uint64 {
+	if x != nil {
+		return x.RawSize
+	}
+	return 0
+}
+
+func (x *HotKeyRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HotKeyRequest) GetLeaseMs() int64 {
+	if x != nil {
+		return x.LeaseMs
+	}
+	return 0
+}
+
+type HotKeyResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Success bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Err     string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	// 提升时返回接收方上次推送以来该热点的命中次数，主节点据此判断热点是否仍然活跃
+	Hits          uint64 `protobuf:"varint,3,opt,name=hits,proto3" json:"hits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HotKeyResponse) Reset() {
+	*x = HotKeyResponse{}
+	mi := &file_proto_distcache_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HotKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HotKeyResponse) ProtoMessage() {}
+
+func (x *HotKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_distcache_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HotKeyResponse.ProtoReflect.Descriptor instead.
+func (*HotKeyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_distcache_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *HotKeyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *HotKeyResponse) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+func (x *HotKeyResponse) GetHits() uint64 {
+	if x != nil {
+		return x.Hits
+	}
+	return 0
+}
+
 var File_proto_distcache_proto protoreflect.FileDescriptor
 
 const file_proto_distcache_proto_rawDesc = "" +
@@ -360,16 +694,28 @@ const file_proto_distcache_proto_rawDesc = "" +
 	"\n" +
 	"GetRequest\x12\x14\n" +
 	"\x05group\x18\x01 \x01(\tR\x05group\x12\x10\n" +
-	"\x03key\x18\x02 \x01(\tR\x03key\"I\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"\x97\x01\n" +
 	"\vGetResponse\x12\x12\n" +
 	"\x04data\x18\x01 \x01(\fR\x04data\x12\x14\n" +
 	"\x05found\x18\x02 \x01(\bR\x05found\x12\x10\n" +
-	"\x03err\x18\x03 \x01(\tR\x03err\"H\n" +
+	"\x03err\x18\x03 \x01(\tR\x03err\x12\x1b\n" +
+	"\tnot_found\x18\x04 \x01(\bR\bnotFound\x12\x14\n" +
+	"\x05codec\x18\x05 \x01(\rR\x05codec\x12\x19\n" +
+	"\braw_size\x18\x06 \x01(\x04R\arawSize\";\n" +
+	"\x0fBatchGetRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\"V\n" +
+	"\x10BatchGetResponse\x120\n" +
+	"\aresults\x18\x01 \x03(\v2\x16.distcache.GetResponseR\aresults\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\x96\x01\n" +
 	"\n" +
 	"SetRequest\x12\x14\n" +
 	"\x05group\x18\x01 \x01(\tR\x05group\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x12\n" +
-	"\x04data\x18\x03 \x01(\fR\x04data\"9\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x1b\n" +
+	"\texpire_at\x18\x04 \x01(\x03R\bexpireAt\x12\x14\n" +
+	"\x05codec\x18\x05 \x01(\rR\x05codec\x12\x19\n" +
+	"\braw_size\x18\x06 \x01(\x04R\arawSize\"9\n" +
 	"\vSetResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x10\n" +
 	"\x03err\x18\x02 \x01(\tR\x03err\"7\n" +
@@ -378,11 +724,27 @@ const file_proto_distcache_proto_rawDesc = "" +
 	"\x03key\x18\x02 \x01(\tR\x03key\"<\n" +
 	"\x0eDeleteResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x10\n" +
-	"\x03err\x18\x02 \x01(\tR\x03err2\xb9\x01\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\xe8\x01\n" +
+	"\rHotKeyRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x18\n" +
+	"\apromote\x18\x03 \x01(\bR\apromote\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\x12\x1b\n" +
+	"\texpire_at\x18\x05 \x01(\x03R\bexpireAt\x12\x14\n" +
+	"\x05codec\x18\x06 \x01(\rR\x05codec\x12\x19\n" +
+	"\braw_size\x18\a \x01(\x04R\arawSize\x12\x18\n" +
+	"\aversion\x18\b \x01(\x04R\aversion\x12\x19\n" +
+	"\blease_ms\x18\t \x01(\x03R\aleaseMs\"P\n" +
+	"\x0eHotKeyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\x12\x12\n" +
+	"\x04hits\x18\x03 \x01(\x04R\x04hits2\xbd\x02\n" +
 	"\fCacheService\x124\n" +
 	"\x03Get\x12\x15.distcache.GetRequest\x1a\x16.distcache.GetResponse\x124\n" +
 	"\x03Set\x12\x15.distcache.SetRequest\x1a\x16.distcache.SetResponse\x12=\n" +
-	"\x06Delete\x12\x18.distcache.DeleteRequest\x1a\x19.distcache.DeleteResponseB(Z&github.com/simplely77/distcache/proto;b\x06proto3"
+	"\x06Delete\x12\x18.distcache.DeleteRequest\x1a\x19.distcache.DeleteResponse\x12C\n" +
+	"\bBatchGet\x12\x1a.distcache.BatchGetRequest\x1a\x1b.distcache.BatchGetResponse\x12=\n" +
+	"\x06HotKey\x12\x18.distcache.HotKeyRequest\x1a\x19.distcache.HotKeyResponseB(Z&github.com/simplely77/distcache/proto;b\x06proto3"
 
 var (
 	file_proto_distcache_proto_rawDescOnce sync.Once
@@ -396,27 +758,36 @@ func file_proto_distcache_proto_rawDescGZIP() []byte {
 	return file_proto_distcache_proto_rawDescData
 }
 
-var file_proto_distcache_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_distcache_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proto_distcache_proto_goTypes = []any{
-	(*GetRequest)(nil),     // 0: distcache.GetRequest
-	(*GetResponse)(nil),    // 1: distcache.GetResponse
-	(*SetRequest)(nil),     // 2: distcache.SetRequest
-	(*SetResponse)(nil),    // 3: distcache.SetResponse
-	(*DeleteRequest)(nil),  // 4: distcache.DeleteRequest
-	(*DeleteResponse)(nil), // 5: distcache.DeleteResponse
+	(*GetRequest)(nil),       // 0: distcache.GetRequest
+	(*GetResponse)(nil),      // 1: distcache.GetResponse
+	(*BatchGetRequest)(nil),  // 2: distcache.BatchGetRequest
+	(*BatchGetResponse)(nil), // 3: distcache.BatchGetResponse
+	(*SetRequest)(nil),       // 4: distcache.SetRequest
+	(*SetResponse)(nil),      // 5: distcache.SetResponse
+	(*DeleteRequest)(nil),    // 6: distcache.DeleteRequest
+	(*DeleteResponse)(nil),   // 7: distcache.DeleteResponse
+	(*HotKeyRequest)(nil),    // 8: distcache.HotKeyRequest
+	(*HotKeyResponse)(nil),   // 9: distcache.HotKeyResponse
 }
 var file_proto_distcache_proto_depIdxs = []int32{
-	0, // 0: distcache.CacheService.Get:input_type -> distcache.GetRequest
-	2, // 1: distcache.CacheService.Set:input_type -> distcache.SetRequest
-	4, // 2: distcache.CacheService.Delete:input_type -> distcache.DeleteRequest
-	1, // 3: distcache.CacheService.Get:output_type -> distcache.GetResponse
-	3, // 4: distcache.CacheService.Set:output_type -> distcache.SetResponse
-	5, // 5: distcache.CacheService.Delete:output_type -> distcache.DeleteResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: distcache.BatchGetResponse.results:type_name -> distcache.GetResponse
+	0, // 1: distcache.CacheService.Get:input_type -> distcache.GetRequest
+	4, // 2: distcache.CacheService.Set:input_type -> distcache.SetRequest
+	6, // 3: distcache.CacheService.Delete:input_type -> distcache.DeleteRequest
+	2, // 4: distcache.CacheService.BatchGet:input_type -> distcache.BatchGetRequest
+	8, // 5: distcache.CacheService.HotKey:input_type -> distcache.HotKeyRequest
+	1, // 6: distcache.CacheService.Get:output_type -> distcache.GetResponse
+	5, // 7: distcache.CacheService.Set:output_type -> distcache.SetResponse
+	7, // 8: distcache.CacheService.Delete:output_type -> distcache.DeleteResponse
+	3, // 9: distcache.CacheService.BatchGet:output_type -> distcache.BatchGetResponse
+	9, // 10: distcache.CacheService.HotKey:output_type -> distcache.HotKeyResponse
+	6, // [6:11] is the sub-list for method output_type
+	1, // [1:6] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_distcache_proto_init() }
@@ -430,7 +801,7 @@ func file_proto_distcache_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_distcache_proto_rawDesc), len(file_proto_distcache_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
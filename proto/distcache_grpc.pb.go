@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CacheService_Get_FullMethodName    = "/distcache.CacheService/Get"
-	CacheService_Set_FullMethodName    = "/distcache.CacheService/Set"
-	CacheService_Delete_FullMethodName = "/distcache.CacheService/Delete"
+	CacheService_Get_FullMethodName      = "/distcache.CacheService/Get"
+	CacheService_Set_FullMethodName      = "/distcache.CacheService/Set"
+	CacheService_Delete_FullMethodName   = "/distcache.CacheService/Delete"
+	CacheService_BatchGet_FullMethodName = "/distcache.CacheService/BatchGet"
+	CacheService_HotKey_FullMethodName   = "/distcache.CacheService/HotKey"
 )
 
 // CacheServiceClient is the client API for CacheService service.
@@ -36,6 +38,10 @@ type CacheServiceClient interface {
 	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
 	// 删除 key（对应 Invalidate）
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// 批量获取 key
+	BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error)
+	// 主节点推送热点的提升和撤销
+	HotKey(ctx context.Context, in *HotKeyRequest, opts ...grpc.CallOption) (*HotKeyResponse, error)
 }
 
 type cacheServiceClient struct {
@@ -76,6 +82,26 @@ func (c *cacheServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts
 	return out, nil
 }
 
+func (c *cacheServiceClient) BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetResponse)
+	err := c.cc.Invoke(ctx, CacheService_BatchGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheServiceClient) HotKey(ctx context.Context, in *HotKeyRequest, opts ...grpc.CallOption) (*HotKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HotKeyResponse)
+	err := c.cc.Invoke(ctx, CacheService_HotKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CacheServiceServer is the server API for CacheService service.
 // All implementations must embed UnimplementedCacheServiceServer
 // for forward compatibility.
@@ -88,6 +114,10 @@ type CacheServiceServer interface {
 	Set(context.Context, *SetRequest) (*SetResponse, error)
 	// 删除 key（对应 Invalidate）
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// 批量获取 key
+	BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error)
+	// 主节点推送热点的提升和撤销
+	HotKey(context.Context, *HotKeyRequest) (*HotKeyResponse, error)
 	mustEmbedUnimplementedCacheServiceServer()
 }
 
@@ -107,6 +137,12 @@ func (UnimplementedCacheServiceServer) Set(context.Context, *SetRequest) (*SetRe
 func (UnimplementedCacheServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedCacheServiceServer) BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGet not implemented")
+}
+func (UnimplementedCacheServiceServer) HotKey(context.Context, *HotKeyRequest) (*HotKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HotKey not implemented")
+}
 func (UnimplementedCacheServiceServer) mustEmbedUnimplementedCacheServiceServer() {}
 func (UnimplementedCacheServiceServer) testEmbeddedByValue()                      {}
 
@@ -182,6 +218,42 @@ func _CacheService_Delete_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheService_BatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).BatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_BatchGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).BatchGet(ctx, req.(*BatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheService_HotKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HotKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheServiceServer).HotKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheService_HotKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheServiceServer).HotKey(ctx, req.(*HotKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CacheService_ServiceDesc is the grpc.ServiceDesc for CacheService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -201,6 +273,14 @@ var CacheService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _CacheService_Delete_Handler,
 		},
+		{
+			MethodName: "BatchGet",
+			Handler:    _CacheService_BatchGet_Handler,
+		},
+		{
+			MethodName: "HotKey",
+			Handler:    _CacheService_HotKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/distcache.proto",
@@ -0,0 +1,156 @@
+package sieve
+
+import (
+	"container/list"
+
+	"github.com/simplely77/distcache/lru"
+)
+
+// Value 与 lru.Value 相同，使不同的淘汰策略可以互相替换
+type Value = lru.Value
+
+// Cache 是 SIEVE 淘汰算法的缓存
+// 新条目插入队首，命中时只设置 visited 标记而不移动节点；
+// 淘汰时指针从队尾向队首移动，清除沿途的 visited 标记，淘汰第一个未被访问过的条目
+type Cache struct {
+	// 最大内存
+	maxBytes int64
+	// 当前内存
+	nbytes int64
+	// FIFO 队列，队首为最新插入
+	ll *list.List
+	// 键值对映射
+	cache map[string]*list.Element
+	// 淘汰指针，nil 表示从队尾开始
+	hand *list.Element
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted func(key string, val Value)
+}
+
+type entry struct {
+	key     string
+	value   Value
+	visited bool
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		ll:        list.New(),
+		cache:     make(map[string]*list.Element),
+		onEvicted: onEvicted,
+	}
+}
+
+// Add 添加一个键值对到缓存中，已存在的键更新值并标记为已访问
+func (c *Cache) Add(key string, val Value) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		c.nbytes += int64(val.Len()) - int64(kv.value.Len())
+		kv.value = val
+		kv.visited = true
+	} else {
+		c.cache[key] = c.ll.PushFront(&entry{key: key, value: val})
+		c.nbytes += int64(len(key)) + int64(val.Len())
+	}
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+	}
+}
+
+// Get 查找键对应的值，命中时只标记为已访问
+func (c *Cache) Get(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		kv.visited = true
+		return kv.value, true
+	}
+	return
+}
+
+// Peek 查找键对应的值，但不标记为已访问
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Victim 返回下一个会被淘汰的键，但不移动淘汰指针也不清除 visited 标记
+func (c *Cache) Victim() (key string, ok bool) {
+	start := c.hand
+	if start == nil {
+		start = c.ll.Back()
+	}
+	if start == nil {
+		return
+	}
+	ele := start
+	for ele.Value.(*entry).visited {
+		if ele = ele.Prev(); ele == nil {
+			ele = c.ll.Back()
+		}
+		// 所有条目都被访问过，清除一圈标记后淘汰的是起点
+		if ele == start {
+			break
+		}
+	}
+	return ele.Value.(*entry).key, true
+}
+
+// RemoveOldest 移动淘汰指针并淘汰第一个未被访问过的条目
+func (c *Cache) RemoveOldest() {
+	ele := c.hand
+	if ele == nil {
+		ele = c.ll.Back()
+	}
+	if ele == nil {
+		return
+	}
+	for ele.Value.(*entry).visited {
+		ele.Value.(*entry).visited = false
+		if ele = ele.Prev(); ele == nil {
+			ele = c.ll.Back()
+		}
+	}
+	c.hand = ele.Prev()
+	c.removeElement(ele)
+}
+
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		if c.hand == ele {
+			c.hand = ele.Prev()
+		}
+		c.removeElement(ele)
+	}
+}
+
+// Range 按插入顺序从最早到最新遍历条目，fn 返回 false 时停止，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.ll.Back(); ele != nil; ele = ele.Prev() {
+		kv := ele.Value.(*entry)
+		if !fn(kv.key, kv.value) {
+			return
+		}
+	}
+}
+
+func (c *Cache) Len() int {
+	return c.ll.Len()
+}
+
+// NBytes 返回当前缓存使用的字节数
+func (c *Cache) NBytes() int64 {
+	return c.nbytes
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	c.ll.Remove(ele)
+	kv := ele.Value.(*entry)
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.onEvicted != nil {
+		c.onEvicted(kv.key, kv.value)
+	}
+}
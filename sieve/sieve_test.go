@@ -0,0 +1,103 @@
+package sieve
+
+import (
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+// test Get method of Cache
+func TestGet(t *testing.T) {
+	sieve := New(int64(0), nil)
+	sieve.Add("key1", String("1234"))
+	if v, ok := sieve.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := sieve.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+// test RemoveOldest method of Cache
+func TestRemoveOldest(t *testing.T) {
+	k1, k2, k3 := "key1", "key2", "k3"
+	v1, v2, v3 := "value1", "value2", "v3"
+	cap := len(k1 + k2 + v1 + v2)
+	sieve := New(int64(cap), nil)
+	sieve.Add(k1, String(v1))
+	sieve.Add(k2, String(v2))
+	sieve.Add(k3, String(v3))
+	if _, ok := sieve.Get(k1); ok || sieve.Len() != 2 {
+		t.Fatalf("RemoveOldest key1 failed")
+	}
+}
+
+// test OnEvicted callback function of Cache
+func TestOnEvicted(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	sieve := New(int64(10), callback)
+	sieve.Add("key1", String("123456"))
+	sieve.Add("k2", String("v2"))
+	sieve.Add("k3", String("v3"))
+	sieve.Add("k4", String("v4"))
+	expect := []string{"key1", "k2"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("OnEvicted keys = %v, want %v", keys, expect)
+	}
+}
+
+// test visited entries are skipped by the hand and retained
+func TestVisitedRetained(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	sieve := New(int64(12), callback)
+	sieve.Add("k1", String("v1"))
+	sieve.Add("k2", String("v2"))
+	sieve.Add("k3", String("v3"))
+	sieve.Get("k1")
+	sieve.Add("k4", String("v4"))
+	sieve.Add("k5", String("v5"))
+	expect := []string{"k2", "k3"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("OnEvicted keys = %v, want %v", keys, expect)
+	}
+	if _, ok := sieve.Get("k1"); !ok {
+		t.Fatalf("visited k1 should be retained")
+	}
+}
+
+// test Victim predicts the next eviction without moving the hand
+func TestVictim(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value Value) {
+		keys = append(keys, key)
+	}
+	sieve := New(int64(0), callback)
+	sieve.Add("k1", String("v1"))
+	sieve.Add("k2", String("v2"))
+	sieve.Add("k3", String("v3"))
+	sieve.Get("k1")
+	sieve.Get("k2")
+	for i := 0; i < 3; i++ {
+		victim, ok := sieve.Victim()
+		if !ok || victim != "k3" {
+			t.Fatalf("Victim() = %q, want k3", victim)
+		}
+	}
+	sieve.Get("k3")
+	victim, _ := sieve.Victim()
+	sieve.RemoveOldest()
+	if len(keys) != 1 || keys[0] != victim {
+		t.Fatalf("Victim() = %q, but evicted %v", victim, keys)
+	}
+}
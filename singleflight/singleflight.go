@@ -1,11 +1,14 @@
 package singleflight
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type call struct {
-	wg sync.WaitGroup
+	done  chan struct{}
 	value interface{}
-	err  error
+	err   error
 }
 
 type Group struct{
@@ -20,24 +23,65 @@ func NewGroup()*Group{
 }
 
 func (g *Group) Do(key string,fn func()(interface{},error))(interface{},error){
+	return g.DoContext(context.Background(), key, fn)
+}
+
+// DoContext 与 Do 相同，但每个调用方（包括第一个）只在自己的 ctx 结束时提前返回，不影响其他调用方
+// ctx 可以结束时 fn 在单独的协程中执行，调用方提前返回后仍会执行完，
+// 因此 fn 内部不应使用某个调用方的 ctx，而应使用不随调用方取消的 ctx
+func (g *Group) DoContext(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
 	g.mu.Lock()
 	if g.m==nil{
 		g.m=make(map[string]*call)
 	}
-	if c,ok:=g.m[key];ok{
-		g.mu.Unlock()
-		c.wg.Wait()
-		return c.value,c.err
+	c,ok:=g.m[key]
+	if !ok{
+		c = &call{done: make(chan struct{})}
+		g.m[key]=c
 	}
-	c:=new(call)
-	c.wg.Add(1)
-	g.m[key]=c
 	g.mu.Unlock()
 
-	c.value,c.err=fn()
-	c.wg.Done()
+	if !ok{
+		run := func() {
+			c.value,c.err=fn()
+			close(c.done)
+			g.mu.Lock()
+			delete(g.m,key)
+			g.mu.Unlock()
+		}
+		if ctx.Done()==nil{
+			run()
+			return c.value,c.err
+		}
+		go run()
+	}
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire 在 key 没有进行中的调用时登记一个调用并返回 done，由调用方自行执行加载，
+// 完成后必须调用 done 写入结果，期间同一 key 的 Do 会等待这个结果；已有进行中的调用时返回 false
+// 用于一次加载多个 key 的场景，使批量加载与单个 key 的加载共用同一组 key
+func (g *Group) TryAcquire(key string) (done func(value interface{}, err error), ok bool) {
 	g.mu.Lock()
-	delete(g.m,key)
-	g.mu.Unlock()
-	return c.value,c.err
-}
\ No newline at end of file
+	defer g.mu.Unlock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if _, ok := g.m[key]; ok {
+		return nil, false
+	}
+	c := &call{done: make(chan struct{})}
+	g.m[key] = c
+	return func(value interface{}, err error) {
+		c.value, c.err = value, err
+		close(c.done)
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+	}, true
+}
@@ -0,0 +1,272 @@
+package slab
+
+import "encoding/binary"
+
+// Cache 把键值对连续写入一块字节 slab，索引只保存 key 的 64 位哈希到偏移的映射
+// slab 和索引都不含指针，条目再多 GC 也不需要逐个扫描；代价是读取时需要拷贝值
+//
+// 淘汰顺序是带二次机会的 FIFO（CLOCK）：新条目追加到 slab 末尾，命中时只设置 visited 标记，
+// 淘汰时从头部开始，被访问过的条目清除标记后移到末尾，淘汰第一个未被访问过的条目。
+// 删除和覆盖只把旧记录标记为已删除，失效的字节超过 slab 的一半时整体压缩
+type Cache struct {
+	// 最大内存，按 len(key)+len(value) 统计，0 表示不限制
+	maxBytes int64
+	// 当前内存
+	nbytes int64
+	// 记录依次追加，head 之前的字节都已失效
+	buf  []byte
+	head int
+	// head 之后被删除或覆盖的记录占用的字节数
+	dead int
+	// key 的哈希到记录偏移的映射
+	index map[uint64]int
+	// 某条记录被移除时的回调函数，可以为 nil；value 只在回调期间有效
+	onEvicted func(key string, value []byte)
+}
+
+// 记录格式：keyLen uint32 | valueLen uint32 | flags byte | key | value
+const (
+	headerSize = 9
+
+	flagDeleted = 1 << 0
+	flagVisited = 1 << 1
+)
+
+const (
+	// initialSize 是第一次写入时为 slab 预分配的大小，之后按需倍增
+	initialSize = 16 << 10
+	// minCompact 是触发压缩的最小失效字节数，避免小 slab 频繁压缩
+	minCompact = 4 << 10
+)
+
+func New(maxBytes int64, onEvicted func(key string, value []byte)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		index:     make(map[uint64]int),
+		onEvicted: onEvicted,
+	}
+}
+
+// hashKey 是内联的 64 位 FNV-1a，不产生内存分配
+func hashKey[T string | []byte](key T) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
+
+// record 解析 off 处的记录，返回记录总长度
+func (c *Cache) record(off int) (key, value []byte, flags byte, size int) {
+	klen := int(binary.LittleEndian.Uint32(c.buf[off:]))
+	vlen := int(binary.LittleEndian.Uint32(c.buf[off+4:]))
+	flags = c.buf[off+8]
+	start := off + headerSize
+	return c.buf[start : start+klen], c.buf[start+klen : start+klen+vlen], flags, headerSize + klen + vlen
+}
+
+// lookup 返回 key 对应记录的偏移，哈希冲突但 key 不同时视为不存在
+func (c *Cache) lookup(key string) (int, bool) {
+	off, ok := c.index[hashKey(key)]
+	if !ok {
+		return 0, false
+	}
+	if k, _, _, _ := c.record(off); string(k) != key {
+		return 0, false
+	}
+	return off, true
+}
+
+// appendRecord 在 slab 末尾追加一条记录并返回偏移
+func (c *Cache) appendRecord(key, value []byte, flags byte) int {
+	if c.buf == nil {
+		c.buf = make([]byte, 0, initialSize)
+	}
+	off := len(c.buf)
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint32(header[0:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(value)))
+	header[8] = flags
+	c.buf = append(c.buf, header[:]...)
+	c.buf = append(c.buf, key...)
+	c.buf = append(c.buf, value...)
+	return off
+}
+
+// Add 添加一个键值对到缓存中，已存在的键写入新记录并删除旧记录
+// 两个不同的 key 哈希冲突时，旧 key 被淘汰并调用回调
+func (c *Cache) Add(key string, value []byte) {
+	h := hashKey(key)
+	if off, ok := c.index[h]; ok {
+		k, _, _, _ := c.record(off)
+		if string(k) == key {
+			c.kill(off)
+		} else {
+			c.evict(off)
+		}
+	}
+	c.index[h] = c.appendRecord([]byte(key), value, 0)
+	c.nbytes += int64(len(key) + len(value))
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+	}
+	c.maybeCompact()
+}
+
+// Get 查找键对应的值，返回值的拷贝，命中时标记为已访问
+func (c *Cache) Get(key string) ([]byte, bool) {
+	off, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.buf[off+8] |= flagVisited
+	_, v, _, _ := c.record(off)
+	return append([]byte(nil), v...), true
+}
+
+// Peek 查找键对应的值，返回值的拷贝，不标记为已访问
+func (c *Cache) Peek(key string) ([]byte, bool) {
+	off, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	_, v, _, _ := c.record(off)
+	return append([]byte(nil), v...), true
+}
+
+// Update 命中时标记为已访问，并在 slab 中原地修改值
+// fn 不能改变值的长度，也不能在返回后继续持有 value
+func (c *Cache) Update(key string, fn func(value []byte)) bool {
+	off, ok := c.lookup(key)
+	if !ok {
+		return false
+	}
+	c.buf[off+8] |= flagVisited
+	_, v, _, _ := c.record(off)
+	fn(v)
+	return true
+}
+
+// Remove 删除键并调用回调
+func (c *Cache) Remove(key string) {
+	if off, ok := c.lookup(key); ok {
+		c.evict(off)
+		c.maybeCompact()
+	}
+}
+
+// RemoveOldest 按二次机会 FIFO 淘汰一个条目
+func (c *Cache) RemoveOldest() {
+	if off, ok := c.advance(); ok {
+		_, _, _, size := c.record(off)
+		c.evict(off)
+		c.head += size
+		c.dead -= size
+	}
+	c.maybeCompact()
+}
+
+// Victim 返回下一个会被淘汰的键，但不淘汰它
+// 和 RemoveOldest 一样先把头部被访问过的条目清除标记后移到末尾（这些工作淘汰时本来就要做），
+// 返回的就是下一次 RemoveOldest 淘汰的条目，准入检查反复调用 Victim 时也不会重复扫描同一批条目
+func (c *Cache) Victim() (key string, ok bool) {
+	off, ok := c.advance()
+	if ok {
+		k, _, _, _ := c.record(off)
+		key = string(k)
+	}
+	c.maybeCompact()
+	return key, ok
+}
+
+// advance 跳过头部已删除的记录，给被访问过的条目第二次机会，返回第一个未被访问过的条目的偏移
+func (c *Cache) advance() (int, bool) {
+	for c.head < len(c.buf) {
+		off := c.head
+		key, value, flags, size := c.record(off)
+		if flags&flagDeleted != 0 {
+			c.head += size
+			c.dead -= size
+			continue
+		}
+		if flags&flagVisited != 0 {
+			// 清除标记后移到末尾
+			c.head += size
+			c.index[hashKey(key)] = c.appendRecord(key, value, 0)
+			continue
+		}
+		return off, true
+	}
+	return 0, false
+}
+
+// Range 从最早写入到最近写入依次遍历条目，fn 返回 false 时停止
+// value 只在 fn 调用期间有效，遍历期间不能修改缓存
+func (c *Cache) Range(fn func(key string, value []byte) bool) {
+	for off := c.head; off < len(c.buf); {
+		k, v, flags, size := c.record(off)
+		off += size
+		if flags&flagDeleted != 0 {
+			continue
+		}
+		if !fn(string(k), v) {
+			return
+		}
+	}
+}
+
+// kill 把 off 处的记录标记为已删除，不调用回调
+func (c *Cache) kill(off int) {
+	key, value, _, size := c.record(off)
+	c.buf[off+8] |= flagDeleted
+	c.dead += size
+	c.nbytes -= int64(len(key) + len(value))
+	delete(c.index, hashKey(key))
+}
+
+// evict 删除 off 处的记录并调用回调
+func (c *Cache) evict(off int) {
+	key, value, _, _ := c.record(off)
+	c.kill(off)
+	if c.onEvicted != nil {
+		c.onEvicted(string(key), value)
+	}
+}
+
+// maybeCompact 在失效字节超过 slab 一半时，把有效记录依次拷贝到新的 slab
+func (c *Cache) maybeCompact() {
+	garbage := c.head + c.dead
+	if garbage < minCompact || garbage*2 < len(c.buf) {
+		return
+	}
+	live := len(c.buf) - garbage
+	buf := make([]byte, 0, max(live*2, initialSize))
+	for off := c.head; off < len(c.buf); {
+		key, _, flags, size := c.record(off)
+		if flags&flagDeleted == 0 {
+			c.index[hashKey(key)] = len(buf)
+			buf = append(buf, c.buf[off:off+size]...)
+		}
+		off += size
+	}
+	c.buf, c.head, c.dead = buf, 0, 0
+}
+
+func (c *Cache) Len() int {
+	return len(c.index)
+}
+
+// NBytes 返回当前缓存使用的字节数，不包括记录头和失效的记录
+func (c *Cache) NBytes() int64 {
+	return c.nbytes
+}
+
+// SlabBytes 返回 slab 当前占用的字节数，包括记录头和尚未压缩的失效记录
+func (c *Cache) SlabBytes() int {
+	return len(c.buf)
+}
@@ -0,0 +1,151 @@
+package slab
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// test Get method of Cache
+func TestGet(t *testing.T) {
+	c := New(int64(0), nil)
+	c.Add("key1", []byte("1234"))
+	if v, ok := c.Get("key1"); !ok || string(v) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := c.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+	c.Add("key1", []byte("5678"))
+	if v, ok := c.Get("key1"); !ok || string(v) != "5678" || c.Len() != 1 || c.NBytes() != 8 {
+		t.Fatalf("overwrite key1 failed: %q len=%d nbytes=%d", v, c.Len(), c.NBytes())
+	}
+}
+
+// test RemoveOldest method of Cache
+func TestRemoveOldest(t *testing.T) {
+	k1, k2, k3 := "key1", "key2", "k3"
+	v1, v2, v3 := "value1", "value2", "v3"
+	cap := len(k1 + k2 + v1 + v2)
+	c := New(int64(cap), nil)
+	c.Add(k1, []byte(v1))
+	c.Add(k2, []byte(v2))
+	c.Add(k3, []byte(v3))
+	if _, ok := c.Get(k1); ok || c.Len() != 2 {
+		t.Fatalf("RemoveOldest key1 failed")
+	}
+}
+
+// test OnEvicted callback function of Cache
+func TestOnEvicted(t *testing.T) {
+	keys := make([]string, 0)
+	callback := func(key string, value []byte) {
+		keys = append(keys, key+"="+string(value))
+	}
+	c := New(int64(10), callback)
+	c.Add("key1", []byte("123456"))
+	c.Add("k2", []byte("v2"))
+	c.Add("k3", []byte("v3"))
+	c.Add("k4", []byte("v4"))
+	c.Remove("k4")
+	expect := []string{"key1=123456", "k2=v2", "k4=v4"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("OnEvicted keys = %v, want %v", keys, expect)
+	}
+}
+
+// test visited entries get a second chance
+func TestVisitedRetained(t *testing.T) {
+	keys := make([]string, 0)
+	c := New(int64(12), func(key string, value []byte) {
+		keys = append(keys, key)
+	})
+	c.Add("k1", []byte("v1"))
+	c.Add("k2", []byte("v2"))
+	c.Add("k3", []byte("v3"))
+	c.Get("k1")
+	c.Add("k4", []byte("v4"))
+	if !reflect.DeepEqual(keys, []string{"k2"}) {
+		t.Fatalf("evicted %v, want [k2]", keys)
+	}
+	var order []string
+	c.Range(func(key string, value []byte) bool {
+		order = append(order, key)
+		return true
+	})
+	if expect := []string{"k3", "k4", "k1"}; !reflect.DeepEqual(order, expect) {
+		t.Fatalf("Range order = %v, want %v", order, expect)
+	}
+}
+
+// test Victim advances the clock hand like RemoveOldest, so repeated calls do not rescan
+func TestVictim(t *testing.T) {
+	keys := make([]string, 0)
+	c := New(int64(12), func(key string, value []byte) {
+		keys = append(keys, key)
+	})
+	c.Add("k1", []byte("v1"))
+	c.Add("k2", []byte("v2"))
+	c.Add("k3", []byte("v3"))
+	c.Get("k1")
+	if victim, ok := c.Victim(); !ok || victim != "k2" {
+		t.Fatalf("Victim = %q, want k2", victim)
+	}
+	size := c.SlabBytes()
+	if victim, ok := c.Victim(); !ok || victim != "k2" || c.SlabBytes() != size {
+		t.Fatalf("second Victim = %q, slab %d -> %d bytes", victim, size, c.SlabBytes())
+	}
+	c.Add("k4", []byte("v4"))
+	if !reflect.DeepEqual(keys, []string{"k2"}) {
+		t.Fatalf("evicted %v, want [k2]", keys)
+	}
+	// k1 在 Victim 时已经移到末尾，排在之后写入的 k4 前面
+	var order []string
+	c.Range(func(key string, value []byte) bool {
+		order = append(order, key)
+		return true
+	})
+	if expect := []string{"k3", "k1", "k4"}; !reflect.DeepEqual(order, expect) {
+		t.Fatalf("Range order = %v, want %v", order, expect)
+	}
+}
+
+// test Update modifies values in place and Peek does not mark visited
+func TestUpdatePeek(t *testing.T) {
+	c := New(int64(8), nil)
+	c.Add("k1", []byte("v1"))
+	c.Add("k2", []byte("v2"))
+	if !c.Update("k2", func(value []byte) { value[1] = '3' }) {
+		t.Fatal("Update k2 failed")
+	}
+	if v, ok := c.Peek("k1"); !ok || string(v) != "v1" {
+		t.Fatalf("Peek k1 = %q", v)
+	}
+	c.Add("k3", []byte("v3"))
+	if _, ok := c.Peek("k1"); ok {
+		t.Fatal("Peek should not protect k1 from eviction")
+	}
+	if v, ok := c.Get("k2"); !ok || string(v) != "v3" {
+		t.Fatalf("k2 = %q, want v3", v)
+	}
+}
+
+// test deleted and overwritten records are compacted
+func TestCompact(t *testing.T) {
+	c := New(int64(0), nil)
+	value := make([]byte, 100)
+	for i := 0; i < 10000; i++ {
+		c.Add(fmt.Sprintf("key%d", i%10), value)
+	}
+	if c.Len() != 10 {
+		t.Fatalf("Len = %d, want 10", c.Len())
+	}
+	if c.SlabBytes() > 64<<10 {
+		t.Fatalf("slab not compacted: %d bytes", c.SlabBytes())
+	}
+	for i := 0; i < 10; i++ {
+		if v, ok := c.Get(fmt.Sprintf("key%d", i)); !ok || len(v) != 100 {
+			t.Fatalf("key%d lost after compaction", i)
+		}
+	}
+}
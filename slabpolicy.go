@@ -0,0 +1,156 @@
+package distcache
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/simplely77/distcache/lru"
+	"github.com/simplely77/distcache/slab"
+)
+
+// slabEntry 是 cacheEntry 在 slab 中的编码：
+//
+//	expire int64 | inserted int64 | lastAccess int64 | accesses uint64 | flags byte | codec byte | rawLen uint64 | value
+//
+// 时间按 UnixNano 保存，0 表示零值
+const (
+	slabEntryHeader = 42
+
+	slabFlagNegative = 1 << 0
+	slabFlagOnDisk   = 1 << 1
+)
+
+// slabPolicy 把分片条目编码后保存在 slab.Cache 中，实现 EvictionPolicy
+// Get/Peek 返回解码出的拷贝，命中时的访问时间和次数直接在 slab 中更新
+type slabPolicy struct {
+	maxBytes  int64
+	slab      *slab.Cache
+	onEvicted func(key string, value lru.Value)
+}
+
+func newSlabPolicy(maxBytes int64, onEvicted func(string, lru.Value)) *slabPolicy {
+	p := &slabPolicy{maxBytes: maxBytes, onEvicted: onEvicted}
+	// 容量由 slabPolicy 按 cacheEntry 的大小控制，slab 自身不限制
+	p.slab = slab.New(0, func(key string, b []byte) {
+		if p.onEvicted != nil {
+			p.onEvicted(key, decodeSlabEntry(b, true))
+		}
+	})
+	return p
+}
+
+func encodeSlabTime(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+func decodeSlabTime(n uint64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(n))
+}
+
+func encodeSlabEntry(e *cacheEntry) []byte {
+	b := make([]byte, slabEntryHeader+len(e.value.b))
+	binary.LittleEndian.PutUint64(b[0:], encodeSlabTime(e.value.expire))
+	binary.LittleEndian.PutUint64(b[8:], encodeSlabTime(e.inserted))
+	binary.LittleEndian.PutUint64(b[16:], encodeSlabTime(e.lastAccess))
+	binary.LittleEndian.PutUint64(b[24:], e.accesses)
+	if e.value.negative {
+		b[32] |= slabFlagNegative
+	}
+	if e.onDisk {
+		b[32] |= slabFlagOnDisk
+	}
+	b[33] = byte(e.value.codec)
+	binary.LittleEndian.PutUint64(b[34:], uint64(e.value.n))
+	copy(b[slabEntryHeader:], e.value.b)
+	return b
+}
+
+// decodeSlabEntry 解码条目，clone 为 true 时拷贝值，否则值引用 b
+func decodeSlabEntry(b []byte, clone bool) *cacheEntry {
+	value := b[slabEntryHeader:]
+	if clone {
+		value = cloneBytes(value)
+	}
+	return &cacheEntry{
+		value: ByteView{
+			b:        value,
+			expire:   decodeSlabTime(binary.LittleEndian.Uint64(b[0:])),
+			negative: b[32]&slabFlagNegative != 0,
+			codec:    Compression(b[33]),
+			n:        int(binary.LittleEndian.Uint64(b[34:])),
+		},
+		inserted:   decodeSlabTime(binary.LittleEndian.Uint64(b[8:])),
+		lastAccess: decodeSlabTime(binary.LittleEndian.Uint64(b[16:])),
+		accesses:   binary.LittleEndian.Uint64(b[24:]),
+		onDisk:     b[32]&slabFlagOnDisk != 0,
+	}
+}
+
+func (p *slabPolicy) Add(key string, value lru.Value) {
+	p.slab.Add(key, encodeSlabEntry(value.(*cacheEntry)))
+	for p.maxBytes != 0 && p.maxBytes < p.NBytes() {
+		p.slab.RemoveOldest()
+	}
+}
+
+// Get 返回条目的拷贝，并在 slab 中记录这次访问
+func (p *slabPolicy) Get(key string) (lru.Value, bool) {
+	var entry *cacheEntry
+	p.slab.Update(key, func(b []byte) {
+		binary.LittleEndian.PutUint64(b[16:], encodeSlabTime(time.Now()))
+		binary.LittleEndian.PutUint64(b[24:], binary.LittleEndian.Uint64(b[24:])+1)
+		entry = decodeSlabEntry(b, true)
+	})
+	if entry == nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (p *slabPolicy) Peek(key string) (lru.Value, bool) {
+	b, ok := p.slab.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	return decodeSlabEntry(b, false), true
+}
+
+func (p *slabPolicy) Remove(key string) {
+	p.slab.Remove(key)
+}
+
+func (p *slabPolicy) RemoveOldest() {
+	p.slab.RemoveOldest()
+}
+
+func (p *slabPolicy) Victim() (string, bool) {
+	return p.slab.Victim()
+}
+
+func (p *slabPolicy) Range(fn func(key string, value lru.Value) bool) {
+	p.slab.Range(func(key string, b []byte) bool {
+		return fn(key, decodeSlabEntry(b, true))
+	})
+}
+
+// rangeBorrowed 实现 borrowRanger，值引用 slab 中的数据，只在 fn 内有效
+func (p *slabPolicy) rangeBorrowed(fn func(key string, value lru.Value) bool) {
+	p.slab.Range(func(key string, b []byte) bool {
+		return fn(key, decodeSlabEntry(b, false))
+	})
+}
+
+func (p *slabPolicy) Len() int {
+	return p.slab.Len()
+}
+
+// NBytes 按 len(key)+value.size() 统计，不包括编码的元数据
+func (p *slabPolicy) NBytes() int64 {
+	return p.slab.NBytes() - int64(p.slab.Len()*slabEntryHeader)
+}
@@ -0,0 +1,271 @@
+package distcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// 快照文件格式（整数均为大端或 varint）：
+//
+//	magic "DCSN" | version uint16
+//	每个条目：tag 1 | uvarint keyLen | key | uvarint valueLen | value | varint expire（UnixNano，0 表示永不过期）| codec byte | uvarint rawLen
+//	（rawLen 是压缩前的长度，只在值被压缩时有意义）
+//	结束：tag 0 | crc32(IEEE，覆盖之前的所有字节) uint32
+//
+// 每个分片内的条目按从最久未使用到最近使用的顺序写入，加载时按同样的顺序写回以保留淘汰顺序
+const (
+	snapshotMagic   = "DCSN"
+	snapshotVersion = 1
+
+	snapshotTagEnd   = 0
+	snapshotTagEntry = 1
+)
+
+// ErrSnapshotCorrupt 表示快照文件格式错误或校验和不匹配
+var ErrSnapshotCorrupt = errors.New("snapshot corrupt")
+
+// SaveSnapshot 将本地缓存中未过期的条目写入 w，负缓存条目不会写入
+// 快照逐个分片复制，写入期间的并发修改不保证包含在快照中
+func (g *Group) SaveSnapshot(w io.Writer) error {
+	if g.closed.Load() {
+		return ErrGroupClosed
+	}
+	bw := bufio.NewWriter(w)
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(bw, crc)
+
+	header := make([]byte, len(snapshotMagic)+2)
+	copy(header, snapshotMagic)
+	binary.BigEndian.PutUint16(header[len(snapshotMagic):], snapshotVersion)
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+
+	var err error
+	buf := make([]byte, 0, 64)
+	g.mainCache.rangeEntries(func(key string, entry cacheEntry) bool {
+		if entry.value.negative {
+			return true
+		}
+		var expire int64
+		if !entry.value.expire.IsZero() {
+			expire = entry.value.expire.UnixNano()
+		}
+		buf = append(buf[:0], snapshotTagEntry)
+		buf = binary.AppendUvarint(buf, uint64(len(key)))
+		buf = append(buf, key...)
+		buf = binary.AppendUvarint(buf, uint64(entry.value.size()))
+		buf = append(buf, entry.value.b...)
+		buf = binary.AppendVarint(buf, expire)
+		buf = append(buf, byte(entry.value.codec))
+		buf = binary.AppendUvarint(buf, uint64(entry.value.n))
+		_, err = out.Write(buf)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := out.Write([]byte{snapshotTagEnd}); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.BigEndian, crc.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadSnapshot 从 r 读取快照并写入本地缓存，返回加载的条目数
+// 整个快照校验通过后才会写入，已过期的条目被跳过；加载的条目只写入本地，不同步到副本
+func (g *Group) LoadSnapshot(r io.Reader) (int, error) {
+	if g.closed.Load() {
+		return 0, ErrGroupClosed
+	}
+	type item struct {
+		key   string
+		value ByteView
+	}
+	crc := crc32.NewIEEE()
+	sr := &snapshotReader{r: bufio.NewReader(r), crc: crc}
+
+	header := make([]byte, len(snapshotMagic)+2)
+	if _, err := io.ReadFull(sr, header); err != nil {
+		return 0, fmt.Errorf("%w: read header: %v", ErrSnapshotCorrupt, err)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, fmt.Errorf("%w: bad magic", ErrSnapshotCorrupt)
+	}
+	version := binary.BigEndian.Uint16(header[len(snapshotMagic):])
+	if version != snapshotVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrSnapshotCorrupt, version)
+	}
+
+	var items []item
+	for {
+		tag, err := sr.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+		}
+		if tag == snapshotTagEnd {
+			break
+		}
+		if tag != snapshotTagEntry {
+			return 0, fmt.Errorf("%w: bad tag %d", ErrSnapshotCorrupt, tag)
+		}
+		key, err := sr.readBytes()
+		if err != nil {
+			return 0, fmt.Errorf("%w: read key: %v", ErrSnapshotCorrupt, err)
+		}
+		value, err := sr.readBytes()
+		if err != nil {
+			return 0, fmt.Errorf("%w: read value: %v", ErrSnapshotCorrupt, err)
+		}
+		expire, err := binary.ReadVarint(sr)
+		if err != nil {
+			return 0, fmt.Errorf("%w: read expire: %v", ErrSnapshotCorrupt, err)
+		}
+		codec, err := sr.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("%w: read codec: %v", ErrSnapshotCorrupt, err)
+		}
+		rawLen, err := binary.ReadUvarint(sr)
+		if err != nil {
+			return 0, fmt.Errorf("%w: read raw length: %v", ErrSnapshotCorrupt, err)
+		}
+		v, err := compressedView(value, Compression(codec), int(rawLen))
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+		}
+		if expire != 0 {
+			v.expire = time.Unix(0, expire)
+		}
+		items = append(items, item{string(key), v})
+	}
+
+	sum := crc.Sum32()
+	var want uint32
+	if err := binary.Read(sr.r, binary.BigEndian, &want); err != nil {
+		return 0, fmt.Errorf("%w: read checksum: %v", ErrSnapshotCorrupt, err)
+	}
+	if sum != want {
+		return 0, fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+
+	now := time.Now()
+	n := 0
+	for _, it := range items {
+		if it.value.expired(now) {
+			continue
+		}
+		// 恢复不是真实访问，不经过 setCache，避免把快照中的每个 key 都计入热点和准入统计
+		g.mainCache.addWith(it.key, g.mainCache.compressor.compress(it.value), addRestore)
+		g.AddBloomKeys(it.key)
+		n++
+	}
+	return n, nil
+}
+
+// snapshotReader 在读取的同时计算校验和
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (sr *snapshotReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	sr.crc.Write(p[:n])
+	return n, err
+}
+
+func (sr *snapshotReader) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err == nil {
+		sr.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+// readBytes 读取一个 uvarint 长度前缀的字节串
+func (sr *snapshotReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(sr)
+	if err != nil {
+		return nil, err
+	}
+	// 长度不可信，按块读取，避免损坏的长度导致一次性分配过多内存
+	var b []byte
+	for n > 0 {
+		chunk := min(n, 64<<10)
+		buf := make([]byte, chunk)
+		if _, err := io.ReadFull(sr, buf); err != nil {
+			return nil, err
+		}
+		b = append(b, buf...)
+		n -= chunk
+	}
+	return b, nil
+}
+
+// saveSnapshotFile 将快照写入临时文件后再重命名，避免中途失败留下不完整的快照
+func (g *Group) saveSnapshotFile(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := g.SaveSnapshot(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	// 重命名前先落盘，避免宕机后留下一个已改名但内容不完整的快照
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadSnapshotFile 从文件加载快照，文件不存在时什么也不做
+func (g *Group) loadSnapshotFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return g.LoadSnapshot(f)
+}
+
+// SaveSnapshots 为所有配置了 WithSnapshotPath 的 Group 保存快照，GRPCPool.Stop 会自动调用
+func SaveSnapshots() error {
+	mu.RLock()
+	var targets []*Group
+	for _, g := range groups {
+		if g.snapshotPath != "" {
+			targets = append(targets, g)
+		}
+	}
+	mu.RUnlock()
+
+	var errs []error
+	for _, g := range targets {
+		if err := g.saveSnapshotFile(g.snapshotPath); err != nil {
+			errs = append(errs, fmt.Errorf("group %s: %w", g.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
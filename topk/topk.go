@@ -0,0 +1,183 @@
+package topk
+
+import (
+	"container/heap"
+	"hash/maphash"
+	"sort"
+	"sync"
+)
+
+// DefaultStripes 是 Tracker 默认的分段数
+const DefaultStripes = 16
+
+// Item 是一个被跟踪的 key 及其估计次数
+type Item struct {
+	Key string
+	// Count 是估计的出现次数，只会偏高，最多偏高 Error
+	Count uint64
+	// Error 是 Count 可能的最大高估值，即该 key 接管计数器时原计数器的值
+	Error uint64
+}
+
+// Tracker 用 Space-Saving 算法跟踪出现次数最多的 key，并发安全
+// key 按哈希分到多个分段，每段独立加锁并保留 k 个计数器，
+// 同一个 key 总是落在同一个分段，合并各段的结果即得到全局的 top-k
+type Tracker struct {
+	k       int
+	seed    maphash.Seed
+	stripes []stripe
+}
+
+type stripe struct {
+	mu       sync.Mutex
+	counters minHeap
+	index    map[string]*counter
+}
+
+type counter struct {
+	key   string
+	count uint64
+	err   uint64
+	pos   int // 在堆中的下标
+}
+
+// New 创建一个跟踪前 k 个 key 的 Tracker
+func New(k int) *Tracker {
+	return NewWithStripes(k, DefaultStripes)
+}
+
+// NewWithStripes 创建一个指定分段数的 Tracker，分段越多锁竞争越少，内存占用越大
+func NewWithStripes(k, stripes int) *Tracker {
+	if k <= 0 {
+		k = 1
+	}
+	if stripes <= 0 {
+		stripes = 1
+	}
+	t := &Tracker{k: k, seed: maphash.MakeSeed(), stripes: make([]stripe, stripes)}
+	for i := range t.stripes {
+		t.stripes[i].index = make(map[string]*counter, k)
+	}
+	return t
+}
+
+func (t *Tracker) stripe(key string) *stripe {
+	if len(t.stripes) == 1 {
+		return &t.stripes[0]
+	}
+	return &t.stripes[maphash.String(t.seed, key)%uint64(len(t.stripes))]
+}
+
+// Add 记录 key 出现了 n 次
+// 计数器已满时，key 接管计数最小的计数器，并继承其计数作为误差
+func (t *Tracker) Add(key string, n uint64) {
+	s := t.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.index[key]; ok {
+		c.count += n
+		heap.Fix(&s.counters, c.pos)
+		return
+	}
+	if len(s.counters) < t.k {
+		c := &counter{key: key, count: n}
+		s.index[key] = c
+		heap.Push(&s.counters, c)
+		return
+	}
+	c := s.counters[0]
+	delete(s.index, c.key)
+	c.key, c.err = key, c.count
+	c.count += n
+	s.index[key] = c
+	heap.Fix(&s.counters, 0)
+}
+
+// Count 返回 key 的估计次数，key 未被跟踪时返回 false
+func (t *Tracker) Count(key string) (Item, bool) {
+	s := t.stripe(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.index[key]
+	if !ok {
+		return Item{}, false
+	}
+	return Item{Key: c.key, Count: c.count, Error: c.err}, true
+}
+
+// Top 返回估计次数最多的 n 个 key，按次数从高到低排序，n <= 0 时返回全部被跟踪的 key
+func (t *Tracker) Top(n int) []Item {
+	var items []Item
+	for i := range t.stripes {
+		s := &t.stripes[i]
+		s.mu.Lock()
+		for _, c := range s.counters {
+			items = append(items, Item{Key: c.key, Count: c.count, Error: c.err})
+		}
+		s.mu.Unlock()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	if n > 0 && len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
+
+// Decay 将所有计数减半，计数归零的 key 不再被跟踪
+// 减半不改变计数之间的大小关系，不需要重建堆
+func (t *Tracker) Decay() {
+	for i := range t.stripes {
+		s := &t.stripes[i]
+		s.mu.Lock()
+		for _, c := range s.counters {
+			c.count /= 2
+			c.err /= 2
+		}
+		for len(s.counters) > 0 && s.counters[0].count == 0 {
+			c := heap.Pop(&s.counters).(*counter)
+			delete(s.index, c.key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Reset 清空所有计数
+func (t *Tracker) Reset() {
+	for i := range t.stripes {
+		s := &t.stripes[i]
+		s.mu.Lock()
+		s.counters = nil
+		s.index = make(map[string]*counter, t.k)
+		s.mu.Unlock()
+	}
+}
+
+// minHeap 是按计数排序的小顶堆，实现 heap.Interface
+type minHeap []*counter
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h minHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *minHeap) Push(x any) {
+	c := x.(*counter)
+	c.pos = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *minHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return c
+}
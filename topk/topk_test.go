@@ -0,0 +1,104 @@
+package topk
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// 计数器足够时计数是精确的
+func TestTracker_Exact(t *testing.T) {
+	tr := NewWithStripes(10, 1)
+	counts := map[string]uint64{"a": 5, "b": 3, "c": 8, "d": 1}
+	for key, n := range counts {
+		for i := uint64(0); i < n; i++ {
+			tr.Add(key, 1)
+		}
+	}
+	expect := []Item{{"c", 8, 0}, {"a", 5, 0}, {"b", 3, 0}}
+	if got := tr.Top(3); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("Top(3) = %v, want %v", got, expect)
+	}
+	if got := tr.Top(0); len(got) != 4 {
+		t.Fatalf("Top(0) returned %d items, want 4", len(got))
+	}
+	if item, ok := tr.Count("d"); !ok || item.Count != 1 {
+		t.Fatalf("Count(d) = %v, %v", item, ok)
+	}
+}
+
+// 计数器不足时，新 key 接管最小的计数器并记录误差
+func TestTracker_Replace(t *testing.T) {
+	tr := NewWithStripes(2, 1)
+	tr.Add("a", 5)
+	tr.Add("b", 2)
+	tr.Add("c", 1)
+	if _, ok := tr.Count("b"); ok {
+		t.Fatal("b should be replaced by c")
+	}
+	item, ok := tr.Count("c")
+	if !ok || item.Count != 3 || item.Error != 2 {
+		t.Fatalf("Count(c) = %+v, want count 3 error 2", item)
+	}
+}
+
+// 偏斜分布下高频 key 一定会被找到
+func TestTracker_HeavyHitters(t *testing.T) {
+	tr := New(20)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		if i%4 == 0 {
+			tr.Add(fmt.Sprintf("hot-%d", r.Intn(5)), 1)
+		} else {
+			tr.Add(fmt.Sprintf("cold-%d", r.Intn(50000)), 1)
+		}
+	}
+	top := tr.Top(5)
+	for _, item := range top {
+		if item.Key[:4] != "hot-" {
+			t.Fatalf("unexpected key in top 5: %+v", top)
+		}
+		if item.Count < 4000 {
+			t.Fatalf("%s count %d is too low", item.Key, item.Count)
+		}
+	}
+}
+
+func TestTracker_Decay(t *testing.T) {
+	tr := NewWithStripes(10, 1)
+	tr.Add("a", 4)
+	tr.Add("b", 1)
+	tr.Decay()
+	if item, ok := tr.Count("a"); !ok || item.Count != 2 {
+		t.Fatalf("Count(a) after decay = %+v", item)
+	}
+	if _, ok := tr.Count("b"); ok {
+		t.Fatal("b should be dropped after decaying to zero")
+	}
+	tr.Reset()
+	if len(tr.Top(0)) != 0 {
+		t.Fatal("Reset should drop all keys")
+	}
+}
+
+func TestTracker_Concurrent(t *testing.T) {
+	tr := New(10)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tr.Add(fmt.Sprintf("key-%d", i%5), 1)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, item := range tr.Top(5) {
+		if item.Count != 1600 {
+			t.Fatalf("%s = %d, want 1600", item.Key, item.Count)
+		}
+	}
+}
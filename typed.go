@@ -0,0 +1,139 @@
+package distcache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/simplely77/distcache/lru"
+)
+
+// DefaultDecodedBytesRatio 解码结果最多占用 cacheBytes 的 1/DefaultDecodedBytesRatio
+const DefaultDecodedBytesRatio = 8
+
+// TypedGroup 在 Group 之上提供类型化的读写，值通过 Codec 编解码
+// 同一份缓存数据只解码一次，解码结果会被所有调用方共享，调用方不应修改返回的值
+// 解码结果按原始数据的大小计入 Group 的容量，底层条目离开缓存时一并丢弃
+type TypedGroup[T any] struct {
+	group *Group
+	codec Codec[T]
+	// 解码结果缓存，key -> *typedEntry[T]
+	mu      sync.Mutex
+	decoded *lru.Cache
+}
+
+// typedEntry 记录解码结果及其对应的原始数据，原始数据变化时需要重新解码
+type typedEntry[T any] struct {
+	view  ByteView
+	value T
+}
+
+func (e *typedEntry[T]) Len() int {
+	return e.view.Len()
+}
+
+// NewTypedGroup 创建一个类型化的 Group，loader 在缓存未命中时加载源数据，opts 用于配置底层的 Group
+func NewTypedGroup[T any](name string, cacheBytes int64, codec Codec[T], loader func(ctx context.Context, key string) (T, error), opts ...Option) *TypedGroup[T] {
+	if codec == nil {
+		panic("nil codec")
+	}
+	if loader == nil {
+		panic("nil loader")
+	}
+	getter := ContextGetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+		v, err := loader(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Marshal(v)
+	})
+	tg := &TypedGroup[T]{
+		group:   NewGroupWithOptions(name, getter, append([]Option{WithCacheBytes(cacheBytes)}, opts...)...),
+		codec:   codec,
+		decoded: lru.New(cacheBytes/DefaultDecodedBytesRatio, nil),
+	}
+	tg.group.OnEvict(func(key string, _ ByteView, _ EvictReason) {
+		tg.forget(key)
+	})
+	return tg
+}
+
+// Group 返回底层的 Group，可用于注册节点等操作
+func (tg *TypedGroup[T]) Group() *Group {
+	return tg.group
+}
+
+// Get 获取 key 对应的值
+func (tg *TypedGroup[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+	view, err := tg.group.GetContext(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+
+	tg.mu.Lock()
+	if v, ok := tg.decoded.Get(key); ok {
+		e := v.(*typedEntry[T])
+		if sameBytes(e.view.b, view.b) {
+			tg.mu.Unlock()
+			return e.value, nil
+		}
+	}
+	tg.mu.Unlock()
+
+	data, err := view.raw()
+	if err != nil {
+		return zero, err
+	}
+	value, err := tg.codec.Unmarshal(data)
+	if err != nil {
+		return zero, err
+	}
+	tg.mu.Lock()
+	before := tg.decoded.NBytes()
+	tg.decoded.Add(key, &typedEntry[T]{view: view, value: value})
+	delta := tg.decoded.NBytes() - before
+	tg.mu.Unlock()
+	// 解锁后再计入容量，淘汰回调会调用 forget
+	tg.group.mainCache.charge(delta)
+	return value, nil
+}
+
+// sameBytes 判断两个切片是否引用同一段内存
+// 缓存中的 ByteView 不会被修改，引用相同时内容一定相同，不必逐字节比较；
+// 返回拷贝的淘汰策略（如 SlabPolicy）下引用总是不同，这时每次都重新解码
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Set 编码后写入缓存，opts 可以为 nil
+func (tg *TypedGroup[T]) Set(ctx context.Context, key string, value T, opts *SetOptions) error {
+	data, err := tg.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	tg.forget(key)
+	return tg.group.SetContext(ctx, key, data, opts)
+}
+
+// Delete 删除 key 及其解码结果
+func (tg *TypedGroup[T]) Delete(key string) {
+	tg.forget(key)
+	tg.group.Delete(key)
+}
+
+// Close 关闭底层的 Group 并释放解码结果
+func (tg *TypedGroup[T]) Close() {
+	tg.group.Close()
+	tg.mu.Lock()
+	tg.decoded = lru.New(0, nil)
+	tg.mu.Unlock()
+}
+
+func (tg *TypedGroup[T]) forget(key string) {
+	tg.mu.Lock()
+	before := tg.decoded.NBytes()
+	tg.decoded.Remove(key)
+	delta := tg.decoded.NBytes() - before
+	tg.mu.Unlock()
+	tg.group.mainCache.charge(delta)
+}
@@ -0,0 +1,100 @@
+package distcache
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/simplely77/distcache/proto"
+)
+
+type score struct {
+	Name  string
+	Value int
+}
+
+// countingCodec 统计解码次数
+type countingCodec[T any] struct {
+	Codec[T]
+	unmarshals int
+}
+
+func (c *countingCodec[T]) Unmarshal(data []byte) (T, error) {
+	c.unmarshals++
+	return c.Codec.Unmarshal(data)
+}
+
+// 测试类型化读写，同一份数据只解码一次
+func TestTypedGroup_JSON(t *testing.T) {
+	codec := &countingCodec[score]{Codec: JSONCodec[score]{}}
+	loads := 0
+	tg := NewTypedGroup[score]("typed-json", 2<<20, codec, func(ctx context.Context, key string) (score, error) {
+		loads++
+		return score{Name: key, Value: len(key)}, nil
+	})
+	defer tg.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		v, err := tg.Get(ctx, "Tom")
+		if err != nil || v.Name != "Tom" || v.Value != 3 {
+			t.Fatalf("unexpected value %v %v", v, err)
+		}
+	}
+	if loads != 1 || codec.unmarshals != 1 {
+		t.Fatalf("expected one load and one decode, got %d loads %d decodes", loads, codec.unmarshals)
+	}
+
+	if err := tg.Set(ctx, "Tom", score{Name: "Tom", Value: 100}, nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, err := tg.Get(ctx, "Tom"); err != nil || v.Value != 100 {
+		t.Fatalf("expected updated value, got %v %v", v, err)
+	}
+}
+
+func TestTypedGroup_Codecs(t *testing.T) {
+	ctx := context.Background()
+
+	gobGroup := NewTypedGroup[score]("typed-gob", 2<<20, GobCodec[score]{}, func(ctx context.Context, key string) (score, error) {
+		return score{Name: key, Value: 42}, nil
+	})
+	defer gobGroup.Close()
+	if v, err := gobGroup.Get(ctx, "Jack"); err != nil || v != (score{Name: "Jack", Value: 42}) {
+		t.Fatalf("gob: unexpected value %v %v", v, err)
+	}
+
+	protoGroup := NewTypedGroup[*pb.GetRequest]("typed-proto", 2<<20, ProtoCodec[*pb.GetRequest]{}, func(ctx context.Context, key string) (*pb.GetRequest, error) {
+		return &pb.GetRequest{Group: "scores", Key: key}, nil
+	})
+	defer protoGroup.Close()
+	if v, err := protoGroup.Get(ctx, "Sam"); err != nil || v.Group != "scores" || v.Key != "Sam" {
+		t.Fatalf("proto: unexpected value %v %v", v, err)
+	}
+}
+
+// 测试解码结果计入 Group 的容量，底层条目被删除或淘汰时一并丢弃
+func TestTypedGroup_DecodedBytes(t *testing.T) {
+	codec := &countingCodec[score]{Codec: JSONCodec[score]{}}
+	tg := NewTypedGroup[score]("typed-bytes", 2<<20, codec, func(ctx context.Context, key string) (score, error) {
+		return score{Name: key, Value: 1}, nil
+	})
+	defer tg.Close()
+
+	ctx := context.Background()
+	if _, err := tg.Get(ctx, "Tom"); err != nil {
+		t.Fatal(err)
+	}
+	c := tg.Group().mainCache
+	shardBytes := c.getShard("Tom").policy.NBytes()
+	if tg.decoded.NBytes() == 0 || c.nbytes.Load() != shardBytes+tg.decoded.NBytes() {
+		t.Fatalf("decoded bytes not charged: total %d, decoded %d", c.nbytes.Load(), tg.decoded.NBytes())
+	}
+
+	tg.Group().Delete("Tom")
+	if tg.decoded.Len() != 0 || c.nbytes.Load() != 0 {
+		t.Fatalf("decoded entry should be dropped with the cache entry: %d entries, %d bytes", tg.decoded.Len(), c.nbytes.Load())
+	}
+	if _, err := tg.Get(ctx, "Tom"); err != nil || codec.unmarshals != 2 {
+		t.Fatalf("expected a second decode, got %d: %v", codec.unmarshals, err)
+	}
+}